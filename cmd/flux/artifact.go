@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+var artifactCmd = &cobra.Command{
+	Use:   "artifact",
+	Short: "Work with OCI artifacts",
+	Long:  "The artifact sub-commands work with OCI artifacts stored in container registries.",
+}
+
+type artifactFlags struct {
+	proxy string
+}
+
+var artifactArgs artifactFlags
+
+func init() {
+	artifactCmd.PersistentFlags().StringVar(&artifactArgs.proxy, "proxy", "",
+		"HTTP(S) or SOCKS5 proxy URL used to connect to the registry, "+
+			"when not specified the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables are used")
+	rootCmd.AddCommand(artifactCmd)
+}
+
+// artifactTransport returns the transport used for the registry and token
+// requests, going through the proxy given with --proxy or configured in the
+// environment.
+func artifactTransport() (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if artifactArgs.proxy != "" {
+		proxyURL, err := url.Parse(artifactArgs.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL '%s': %w", artifactArgs.proxy, err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme '%s', must be one of: http, https, socks5", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport, nil
+}
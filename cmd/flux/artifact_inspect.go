@@ -0,0 +1,230 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var artifactInspectCmd = &cobra.Command{
+	Use:   "inspect [oci://registry/repository:tag]",
+	Short: "Inspect an OCI artifact",
+	Long: `The artifact inspect command prints the annotations, the layers and the presence of
+signatures and attestations of an OCI artifact, without pulling its content.
+Signatures and attestations are looked up with the tag conventions used by cosign.`,
+	Example: `  # Inspect an artifact by tag
+  flux artifact inspect oci://ghcr.io/org/manifests:v1.0.0
+
+  # Inspect an artifact by digest in a private registry
+  flux artifact inspect oci://registry.example.com/manifests@sha256:3a9f... --creds=user:token
+
+  # Inspect an artifact through a SOCKS5 proxy
+  flux artifact inspect oci://ghcr.io/org/manifests:v1.0.0 --proxy=socks5://proxy.example.com:1080
+`,
+	RunE: artifactInspectCmdRun,
+}
+
+type artifactInspectFlags struct {
+	creds    string
+	insecure bool
+}
+
+var artifactInspectArgs artifactInspectFlags
+
+func init() {
+	artifactInspectCmd.Flags().StringVar(&artifactInspectArgs.creds, "creds", "",
+		"credentials for the registry in the format '<username>:<password>'")
+	artifactInspectCmd.Flags().BoolVar(&artifactInspectArgs.insecure, "insecure", false,
+		"use plain HTTP to connect to the registry")
+	artifactCmd.AddCommand(artifactInspectCmd)
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	MediaType   string            `json:"mediaType"`
+	Config      ociDescriptor     `json:"config"`
+	Layers      []ociDescriptor   `json:"layers"`
+	Manifests   []ociDescriptor   `json:"manifests"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+func artifactInspectCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("artifact URL is required")
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	ref, err := parseArtifactURL(args[0], artifactInspectArgs.insecure)
+	if err != nil {
+		return err
+	}
+
+	rt, err := artifactTransport()
+	if err != nil {
+		return err
+	}
+	auth := authn.Anonymous
+	if artifactInspectArgs.creds != "" {
+		username, password := splitCreds(artifactInspectArgs.creds)
+		auth = &authn.Basic{Username: username, Password: password}
+	}
+	options := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithTransport(rt),
+		remote.WithAuth(auth),
+	}
+
+	manifest, digest, err := fetchArtifactManifest(ref, options...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Digest:     %s\n", digest)
+	fmt.Printf("Media type: %s\n", manifest.MediaType)
+	for _, key := range []string{
+		"org.opencontainers.image.source",
+		"org.opencontainers.image.revision",
+		"org.opencontainers.image.created",
+	} {
+		value := manifest.Annotations[key]
+		if value == "" {
+			value = "-"
+		}
+		fmt.Printf("%s: %s\n", strings.TrimPrefix(key, "org.opencontainers.image."), value)
+	}
+
+	var others []string
+	for key := range manifest.Annotations {
+		switch key {
+		case "org.opencontainers.image.source", "org.opencontainers.image.revision", "org.opencontainers.image.created":
+		default:
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	for _, key := range others {
+		fmt.Printf("%s: %s\n", key, manifest.Annotations[key])
+	}
+
+	for _, check := range []struct{ name, suffix string }{
+		{"signature", ".sig"},
+		{"attestation", ".att"},
+	} {
+		tag := ref.Context().Tag(strings.Replace(digest, ":", "-", 1) + check.suffix)
+		found, err := artifactTagExists(tag, options...)
+		if err != nil {
+			return err
+		}
+		status := "not found"
+		if found {
+			status = "found (" + tag.TagStr() + ")"
+		}
+		fmt.Printf("%s: %s\n", check.name, status)
+	}
+	fmt.Println()
+
+	descriptors := manifest.Layers
+	header := []string{"Layer", "Media Type", "Size"}
+	if len(manifest.Manifests) > 0 {
+		descriptors = manifest.Manifests
+		header[0] = "Manifest"
+	}
+	var rows [][]string
+	for _, d := range descriptors {
+		rows = append(rows, []string{d.Digest, d.MediaType, strconv.FormatInt(d.Size, 10)})
+	}
+	utils.PrintTable(os.Stdout, header, rows)
+	return nil
+}
+
+// parseArtifactURL parses an 'oci://<registry>/<repository>[:<tag>|@<digest>]'
+// URL, the tag defaults to latest.
+func parseArtifactURL(artifactURL string, insecure bool) (name.Reference, error) {
+	if !strings.HasPrefix(artifactURL, "oci://") {
+		return nil, fmt.Errorf("invalid artifact URL '%s', must start with oci://", artifactURL)
+	}
+	var opts []name.Option
+	if insecure {
+		opts = append(opts, name.Insecure)
+	}
+	ref, err := name.ParseReference(strings.TrimPrefix(artifactURL, "oci://"), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid artifact URL '%s': %w", artifactURL, err)
+	}
+	return ref, nil
+}
+
+// fetchArtifactManifest returns the manifest or index of an artifact and
+// its digest, computed from the manifest content.
+func fetchArtifactManifest(ref name.Reference, options ...remote.Option) (*ociManifest, string, error) {
+	desc, err := remote.Get(ref, options...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get manifest %s: %w", ref, err)
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(desc.Manifest, &manifest); err != nil {
+		return nil, "", fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = string(desc.MediaType)
+	}
+	return &manifest, desc.Digest.String(), nil
+}
+
+// artifactTagExists tells if the tag is present in the repository.
+func artifactTagExists(tag name.Tag, options ...remote.Option) (bool, error) {
+	if _, err := remote.Head(tag, options...); err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get manifest %s: %w", tag, err)
+	}
+	return true, nil
+}
+
+func splitCreds(creds string) (string, string) {
+	parts := strings.SplitN(creds, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestParseArtifactURL(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	tests := []struct {
+		name       string
+		url        string
+		registry   string
+		repository string
+		reference  string
+		wantErr    bool
+	}{
+		{
+			name:       "tag",
+			url:        "oci://ghcr.io/org/app:v1.0.0",
+			registry:   "ghcr.io",
+			repository: "org/app",
+			reference:  "v1.0.0",
+		},
+		{
+			name:       "digest",
+			url:        "oci://ghcr.io/org/app@" + digest,
+			registry:   "ghcr.io",
+			repository: "org/app",
+			reference:  digest,
+		},
+		{
+			name:       "default tag",
+			url:        "oci://ghcr.io/org/app",
+			registry:   "ghcr.io",
+			repository: "org/app",
+			reference:  "latest",
+		},
+		{
+			name:       "registry with port",
+			url:        "oci://localhost:5000/org/team/app:v1",
+			registry:   "localhost:5000",
+			repository: "org/team/app",
+			reference:  "v1",
+		},
+		{
+			name:    "missing scheme",
+			url:     "ghcr.io/org/app:v1",
+			wantErr: true,
+		},
+		{
+			name:    "empty repository",
+			url:     "oci://ghcr.io/",
+			wantErr: true,
+		},
+		{
+			name:    "invalid digest",
+			url:     "oci://ghcr.io/org/app@sha256:abc",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := parseArtifactURL(tt.url, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseArtifactURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			registry, repository, reference := ref.Context().RegistryStr(), ref.Context().RepositoryStr(), ref.Identifier()
+			if registry != tt.registry || repository != tt.repository || reference != tt.reference {
+				t.Errorf("parseArtifactURL() = %q, %q, %q, want %q, %q, %q",
+					registry, repository, reference, tt.registry, tt.repository, tt.reference)
+			}
+		})
+	}
+}
+
+func TestFetchArtifactManifest(t *testing.T) {
+	body := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json",` +
+		`"config":{"mediaType":"application/vnd.oci.image.config.v1+json","size":2,"digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"},` +
+		`"layers":[],"annotations":{"org.opencontainers.image.revision":"main/abc"}}`
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(body)))
+	signatureTag := strings.Replace(digest, ":", "-", 1) + ".sig"
+
+	// the registry requires a token obtained with the credentials, the
+	// challenge scope holds a comma
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token":"abc"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="registry.example.com",scope="repository:org/app:pull,push"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/":
+		case "/v2/org/app/manifests/v1", "/v2/org/app/manifests/" + signatureTag:
+			w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			w.Header().Set("Docker-Content-Digest", digest)
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			if r.Method == http.MethodGet {
+				fmt.Fprint(w, body)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ref, err := parseArtifactURL(fmt.Sprintf("oci://%s/org/app:v1", server.Listener.Addr()), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := []remote.Option{
+		remote.WithContext(context.TODO()),
+		remote.WithAuth(&authn.Basic{Username: "user", Password: "pass"}),
+	}
+
+	manifest, gotDigest, err := fetchArtifactManifest(ref, options...)
+	if err != nil {
+		t.Fatalf("fetchArtifactManifest() error = %v", err)
+	}
+	if gotDigest != digest {
+		t.Errorf("digest = %q, want %q", gotDigest, digest)
+	}
+	if got := manifest.Annotations["org.opencontainers.image.revision"]; got != "main/abc" {
+		t.Errorf("revision annotation = %q, want main/abc", got)
+	}
+
+	for tag, want := range map[string]bool{
+		signatureTag: true,
+		strings.Replace(digest, ":", "-", 1) + ".att": false,
+	} {
+		found, err := artifactTagExists(ref.Context().Tag(tag), options...)
+		if err != nil {
+			t.Fatalf("artifactTagExists(%s) error = %v", tag, err)
+		}
+		if found != want {
+			t.Errorf("artifactTagExists(%s) = %v, want %v", tag, found, want)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark the reconciliation of sources and Kustomizations",
+	Long: `The bench command creates a number of GitRepositories and Kustomizations pointing at a demo repository,
+measures the time it takes for them to become ready, prints the latency distribution, and then deletes them.
+Each Kustomization applies the manifests in a dedicated namespace, which is deleted during cleanup.`,
+	Example: `  # Measure the reconciliation latency of 50 GitRepositories and Kustomizations
+  flux bench --count=50
+
+  # Benchmark with a custom repository and keep the objects for inspection
+  flux bench --count=20 --url=https://github.com/org/demo --path=./deploy --keep
+`,
+	RunE: benchCmdRun,
+}
+
+type benchFlags struct {
+	count    int
+	url      string
+	branch   string
+	path     string
+	prefix   string
+	interval time.Duration
+	keep     bool
+}
+
+var benchArgs = benchFlags{
+	count:    10,
+	url:      "https://github.com/stefanprodan/podinfo",
+	branch:   "master",
+	path:     "./kustomize",
+	prefix:   "flux-bench",
+	interval: 10 * time.Minute,
+}
+
+// benchLabel is set on all the objects created by the benchmark.
+const benchLabel = "toolkit.fluxcd.io/bench"
+
+func init() {
+	benchCmd.Flags().IntVar(&benchArgs.count, "count", benchArgs.count,
+		"number of GitRepositories and Kustomizations to create")
+	benchCmd.Flags().StringVar(&benchArgs.url, "url", benchArgs.url, "the demo Git repository URL")
+	benchCmd.Flags().StringVar(&benchArgs.branch, "branch", benchArgs.branch, "the demo Git repository branch")
+	benchCmd.Flags().StringVar(&benchArgs.path, "path", benchArgs.path, "path to the manifests in the demo repository")
+	benchCmd.Flags().StringVar(&benchArgs.prefix, "prefix", benchArgs.prefix,
+		"name prefix of the objects and namespaces created by the benchmark")
+	benchCmd.Flags().DurationVar(&benchArgs.interval, "interval", benchArgs.interval, "reconciliation interval of the objects")
+	benchCmd.Flags().BoolVar(&benchArgs.keep, "keep", false, "keep the objects after the benchmark")
+	rootCmd.AddCommand(benchCmd)
+}
+
+// benchObject tracks the readiness of an object created by the benchmark.
+type benchObject struct {
+	obj     client.Object
+	created time.Time
+	latency time.Duration
+	ready   bool
+}
+
+func benchCmdRun(cmd *cobra.Command, args []string) error {
+	if benchArgs.count < 1 {
+		return fmt.Errorf("count must be greater than zero")
+	}
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+
+	if !benchArgs.keep {
+		defer func() {
+			// the command context may be cancelled or expired at this point
+			ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+			defer cancel()
+			cleanupBench(ctx, kubeClient)
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	labels := map[string]string{benchLabel: benchArgs.prefix}
+	var sources, kustomizations []*benchObject
+	logger.Actionf("creating %d GitRepositories and Kustomizations", benchArgs.count)
+	for i := 0; i < benchArgs.count; i++ {
+		name := fmt.Sprintf("%s-%d", benchArgs.prefix, i)
+
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		}
+		if err := kubeClient.Create(ctx, namespace); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+
+		gitRepository := &sourcev1.GitRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: rootArgs.namespace, Labels: labels},
+			Spec: sourcev1.GitRepositorySpec{
+				URL:       benchArgs.url,
+				Interval:  metav1.Duration{Duration: benchArgs.interval},
+				Reference: &sourcev1.GitRepositoryRef{Branch: benchArgs.branch},
+			},
+		}
+		if err := kubeClient.Create(ctx, gitRepository); err != nil {
+			return err
+		}
+		sources = append(sources, &benchObject{obj: gitRepository, created: time.Now()})
+
+		kustomization := &kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: rootArgs.namespace, Labels: labels},
+			Spec: kustomizev1.KustomizationSpec{
+				Interval:        metav1.Duration{Duration: benchArgs.interval},
+				Path:            benchArgs.path,
+				Prune:           true,
+				TargetNamespace: name,
+				SourceRef: kustomizev1.CrossNamespaceSourceReference{
+					Kind: sourcev1.GitRepositoryKind,
+					Name: name,
+				},
+			},
+		}
+		if err := kubeClient.Create(ctx, kustomization); err != nil {
+			return err
+		}
+		kustomizations = append(kustomizations, &benchObject{obj: kustomization, created: time.Now()})
+	}
+	logger.Successf("objects created")
+
+	logger.Waitingf("waiting for the objects to become ready")
+	all := append(sources, kustomizations...)
+	pollErr := pollBenchObjects(ctx, kubeClient, all)
+
+	header := []string{"Kind", "Ready", "Min", "P50", "P90", "P99", "Max"}
+	rows := [][]string{
+		benchRow(sourcev1.GitRepositoryKind, sources),
+		benchRow(kustomizev1.KustomizationKind, kustomizations),
+	}
+	utils.PrintTable(os.Stdout, header, rows)
+
+	if pollErr != nil {
+		return fmt.Errorf("not all objects became ready: %w", pollErr)
+	}
+	logger.Successf("benchmark finished")
+	return nil
+}
+
+// pollBenchObjects records the time at which each object is first seen as
+// ready, until all of them are ready or the context expires.
+func pollBenchObjects(ctx context.Context, kubeClient client.Client, objects []*benchObject) error {
+	ticker := time.NewTicker(rootArgs.pollInterval)
+	defer ticker.Stop()
+	for {
+		pending := 0
+		for _, o := range objects {
+			if o.ready {
+				continue
+			}
+			namespacedName := types.NamespacedName{Namespace: o.obj.GetNamespace(), Name: o.obj.GetName()}
+			if err := kubeClient.Get(ctx, namespacedName, o.obj); err != nil {
+				return err
+			}
+			if isBenchObjectReady(o.obj) {
+				o.ready = true
+				o.latency = time.Since(o.created)
+				continue
+			}
+			pending++
+		}
+		if pending == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func isBenchObjectReady(obj client.Object) bool {
+	var conditions []metav1.Condition
+	switch o := obj.(type) {
+	case *sourcev1.GitRepository:
+		conditions = o.Status.Conditions
+	case *kustomizev1.Kustomization:
+		conditions = o.Status.Conditions
+	}
+	return apimeta.IsStatusConditionTrue(conditions, meta.ReadyCondition)
+}
+
+// benchRow returns the latency distribution of the objects which became ready.
+func benchRow(kind string, objects []*benchObject) []string {
+	var latencies []time.Duration
+	for _, o := range objects {
+		if o.ready {
+			latencies = append(latencies, o.latency)
+		}
+	}
+	row := []string{kind, fmt.Sprintf("%d/%d", len(latencies), len(objects))}
+	if len(latencies) == 0 {
+		return append(row, "-", "-", "-", "-", "-")
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p int) string {
+		i := (len(latencies)*p+99)/100 - 1
+		return latencies[i].Round(time.Millisecond).String()
+	}
+	return append(row,
+		latencies[0].Round(time.Millisecond).String(),
+		percentile(50),
+		percentile(90),
+		percentile(99),
+		latencies[len(latencies)-1].Round(time.Millisecond).String(),
+	)
+}
+
+// cleanupBench deletes the objects created by the benchmark, Kustomizations
+// are deleted first so that the applied manifests are garbage collected.
+func cleanupBench(ctx context.Context, kubeClient client.Client) {
+	logger.Actionf("deleting the benchmark objects")
+	selector := client.MatchingLabels{benchLabel: benchArgs.prefix}
+	failed := false
+
+	for _, obj := range []client.Object{&kustomizev1.Kustomization{}, &sourcev1.GitRepository{}} {
+		if err := kubeClient.DeleteAllOf(ctx, obj, client.InNamespace(rootArgs.namespace), selector); err != nil {
+			logger.Failuref("cleanup failed: %s", err.Error())
+			failed = true
+		}
+	}
+
+	// namespaces can't be deleted as a collection
+	var namespaces corev1.NamespaceList
+	if err := kubeClient.List(ctx, &namespaces, selector); err != nil {
+		logger.Failuref("cleanup failed: %s", err.Error())
+		failed = true
+	}
+	for i := range namespaces.Items {
+		if err := kubeClient.Delete(ctx, &namespaces.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			logger.Failuref("cleanup failed: %s", err.Error())
+			failed = true
+		}
+	}
+
+	if !failed {
+		logger.Successf("benchmark objects deleted")
+	}
+}
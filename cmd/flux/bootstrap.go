@@ -21,16 +21,20 @@ import (
 	"fmt"
 	"net/url"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/git"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 
 	"github.com/fluxcd/flux2/internal/flags"
@@ -60,6 +64,13 @@ type bootstrapFlags struct {
 	requiredComponents []string
 	tokenAuth          bool
 	clusterDomain      string
+	syncSecretRef      string
+	saAnnotations      []string
+	statusFile         string
+	syncIgnore         []string
+	sparsePaths        []string
+	recurseExisting    bool
+	exportExisting     bool
 }
 
 const (
@@ -70,7 +81,7 @@ var bootstrapArgs = NewBootstrapFlags()
 
 func init() {
 	bootstrapCmd.PersistentFlags().StringVarP(&bootstrapArgs.version, "version", "v", rootArgs.defaults.Version,
-		"toolkit version")
+		"toolkit version, can be 'latest', a release e.g. v0.7.1 or a minor release series e.g. v0.7.x for its latest patch")
 	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.defaultComponents, "components", rootArgs.defaults.Components,
 		"list of components, accepts comma-separated values")
 	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.extraComponents, "components-extra", nil,
@@ -91,6 +102,20 @@ func init() {
 	bootstrapCmd.PersistentFlags().Var(&bootstrapArgs.logLevel, "log-level", bootstrapArgs.logLevel.Description())
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.manifestsPath, "manifests", "", "path to the manifest directory")
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.clusterDomain, "cluster-domain", rootArgs.defaults.ClusterDomain, "internal cluster domain")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.syncSecretRef, "sync-secret-ref", "",
+		"name of an existing secret with the Git credentials, when specified the deploy key or token secret is not generated")
+	bootstrapCmd.PersistentFlags().StringArrayVar(&bootstrapArgs.saAnnotations, "sa-annotation", nil,
+		"annotation to set on the controllers service account in the format key=value, e.g. for IRSA or Workload Identity (can be specified multiple times)")
+	bootstrapCmd.PersistentFlags().StringArrayVar(&bootstrapArgs.syncIgnore, "sync-ignore", nil,
+		"gitignore pattern of the files excluded from the sync artifact, set in the GitRepository .spec.ignore")
+	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.sparsePaths, "sparse-path", nil,
+		"repository paths included in the sync artifact, everything else except the cluster path is ignored")
+	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.recurseExisting, "recurse-existing", false,
+		"detect the Flux resources applied outside of Git and adopt them in the bootstrap Kustomization")
+	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.exportExisting, "export-existing", true,
+		"with --recurse-existing, export the existing resources under the bootstrap path, when disabled they are only reported")
+	addStatusFileFlag(bootstrapCmd.PersistentFlags(), &bootstrapArgs.statusFile)
+	addNotifyFlag(bootstrapCmd.PersistentFlags())
 	bootstrapCmd.PersistentFlags().MarkHidden("manifests")
 	bootstrapCmd.PersistentFlags().MarkDeprecated("arch", "multi-arch container image is now available for AMD64, ARMv7 and ARM64")
 	rootCmd.AddCommand(bootstrapCmd)
@@ -119,10 +144,37 @@ func bootstrapValidate() error {
 		return err
 	}
 
+	if _, err := parseServiceAccountAnnotations(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func generateInstallManifests(targetPath, namespace, tmpDir string, localManifests string) (string, error) {
+func parseServiceAccountAnnotations() (map[string]string, error) {
+	result := make(map[string]string)
+	for _, annotation := range bootstrapArgs.saAnnotations {
+		parts := strings.SplitN(annotation, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid service account annotation format '%s', must be key=value", annotation)
+		}
+
+		if errors := validation.IsQualifiedName(parts[0]); len(errors) > 0 {
+			return nil, fmt.Errorf("invalid service account annotation '%s': %v", parts[0], errors)
+		}
+
+		result[parts[0]] = parts[1]
+	}
+
+	return result, nil
+}
+
+func generateInstallManifests(targetPath, namespace, tmpDir string, localManifests string, skipNamespace bool) (string, error) {
+	saAnnotations, err := parseServiceAccountAnnotations()
+	if err != nil {
+		return "", err
+	}
+
 	opts := install.Options{
 		BaseURL:                localManifests,
 		Version:                bootstrapArgs.version,
@@ -138,10 +190,14 @@ func generateInstallManifests(targetPath, namespace, tmpDir string, localManifes
 		Timeout:                rootArgs.timeout,
 		TargetPath:             targetPath,
 		ClusterDomain:          bootstrapArgs.clusterDomain,
+
+		ServiceAccountAnnotations: saAnnotations,
+		SkipNamespace:             skipNamespace,
 	}
 
 	if localManifests == "" {
 		opts.BaseURL = rootArgs.defaults.BaseURL
+		opts.CacheDir = manifestsCacheDir()
 	}
 
 	output, err := install.Generate(opts)
@@ -157,9 +213,9 @@ func generateInstallManifests(targetPath, namespace, tmpDir string, localManifes
 }
 
 func applyInstallManifests(ctx context.Context, manifestPath string, components []string) error {
-	kubectlArgs := []string{"apply", "-f", manifestPath}
-	if _, err := utils.ExecKubectlCommand(ctx, utils.ModeOS, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err != nil {
-		return fmt.Errorf("install failed")
+	kubectlArgs := utils.KubectlApplyArgs("-f", manifestPath)
+	if _, err := utils.ExecKubectlApply(ctx, utils.ModeOS, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err != nil {
+		return installError(err)
 	}
 
 	for _, deployment := range components {
@@ -180,6 +236,9 @@ func generateSyncManifests(url, branch, name, namespace, targetPath, tmpDir stri
 		Interval:     interval,
 		TargetPath:   targetPath,
 		ManifestFile: sync.MakeDefaultOptions().ManifestFile,
+		SecretRef:    bootstrapArgs.syncSecretRef,
+		Ignore:       bootstrapArgs.syncIgnore,
+		SparsePaths:  bootstrapArgs.sparsePaths,
 	}
 
 	manifest, err := sync.Generate(opts)
@@ -199,8 +258,8 @@ func generateSyncManifests(url, branch, name, namespace, targetPath, tmpDir stri
 }
 
 func applySyncManifests(ctx context.Context, kubeClient client.Client, name, namespace, manifestsPath string) error {
-	kubectlArgs := []string{"apply", "-k", manifestsPath}
-	if _, err := utils.ExecKubectlCommand(ctx, utils.ModeStderrOS, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err != nil {
+	kubectlArgs := utils.KubectlApplyArgs("-k", manifestsPath)
+	if _, err := utils.ExecKubectlApply(ctx, utils.ModeStderrOS, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err != nil {
 		return err
 	}
 
@@ -234,6 +293,35 @@ func shouldInstallManifests(ctx context.Context, kubeClient client.Client, names
 	return kustomization.Status.LastAppliedRevision == ""
 }
 
+// isForeignNamespace returns true if the namespace exists and it was not
+// created by a previous install or bootstrap, in which case it is left out
+// of the install manifests so that its labels are not overwritten.
+func isForeignNamespace(ctx context.Context, kubeClient client.Client, namespace string) bool {
+	var existing corev1.Namespace
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: namespace}, &existing); err != nil {
+		return false
+	}
+	return existing.GetLabels()["app.kubernetes.io/instance"] != namespace
+}
+
+// validateSyncSecret checks that the secret given with --sync-secret-ref
+// exists, before any changes are pushed to the repository.
+func validateSyncSecret(ctx context.Context, kubeClient client.Client, namespace string) error {
+	if bootstrapArgs.syncSecretRef == "" {
+		return nil
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: namespace,
+		Name:      bootstrapArgs.syncSecretRef,
+	}
+	var existing corev1.Secret
+	if err := kubeClient.Get(ctx, namespacedName, &existing); err != nil {
+		return fmt.Errorf("sync secret %s not found in %s namespace: %w", bootstrapArgs.syncSecretRef, namespace, err)
+	}
+	return nil
+}
+
 func shouldCreateDeployKey(ctx context.Context, kubeClient client.Client, namespace string) bool {
 	namespacedName := types.NamespacedName{
 		Namespace: namespace,
@@ -276,6 +364,42 @@ func generateDeployKey(ctx context.Context, kubeClient client.Client, url *url.U
 	return string(pair.PublicKey), nil
 }
 
+// repositoryDeleter is implemented by the Git providers that can remove
+// a repository through their API.
+type repositoryDeleter interface {
+	DeleteRepository(ctx context.Context, r *git.Repository) error
+}
+
+// rollbackRepository offers to delete a repository created by a bootstrap
+// run that has been interrupted, so that the next run starts from a clean
+// state. The repository is kept unless the deletion is confirmed.
+func rollbackRepository(provider repositoryDeleter, repository *git.Repository, status *statusFile) {
+	if phases := status.completedPhases(); len(phases) > 0 {
+		logger.Failuref("bootstrap interrupted after completing: %s", strings.Join(phases, ", "))
+	} else {
+		logger.Failuref("bootstrap interrupted before completing any step")
+	}
+
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Delete repository %s created by this bootstrap", repository.GetURL()),
+		IsConfirm: true,
+	}
+	if _, err := prompt.Run(); err != nil {
+		logger.Actionf("repository %s kept", repository.GetURL())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	logger.Actionf("rolling back repository %s", repository.GetURL())
+	if err := provider.DeleteRepository(ctx, repository); err != nil {
+		logger.Failuref("repository %s must be removed manually: %s", repository.GetURL(), err.Error())
+		return
+	}
+	logger.Successf("repository deleted")
+}
+
 func checkIfBootstrapPathDiffers(ctx context.Context, kubeClient client.Client, namespace string, path string) (string, bool) {
 	namespacedName := types.NamespacedName{
 		Name:      namespace,
@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+)
+
+// existingResourcesDir is the directory, relative to the bootstrap path,
+// where the adopted Flux resources are exported.
+const existingResourcesDir = "flux-existing"
+
+// findExistingResources returns the Flux resources in the cluster which
+// are not reconciled from Git, i.e. not labeled by a Kustomization and not
+// part of the bootstrap sync manifests.
+func findExistingResources(ctx context.Context, kubeClient client.Client) ([]unstructured.Unstructured, error) {
+	nameLabel := kustomizev1.GroupVersion.Group + "/name"
+
+	var existing []unstructured.Unstructured
+	for _, k := range namespacedCheckKinds {
+		var list unstructured.UnstructuredList
+		list.SetGroupVersionKind(k.resource.GroupVersion().WithKind(k.kind + "List"))
+		if err := kubeClient.List(ctx, &list); err != nil {
+			if apimeta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("listing %s objects failed: %w", k.kind, err)
+		}
+		for _, item := range list.Items {
+			if _, ok := item.GetLabels()[nameLabel]; ok {
+				continue
+			}
+			if item.GetNamespace() == rootArgs.namespace && item.GetName() == rootArgs.namespace {
+				continue
+			}
+			existing = append(existing, item)
+		}
+	}
+	return existing, nil
+}
+
+// adoptExistingResources exports the Flux resources applied outside of
+// Git under the bootstrap path, commits them with the given function, and
+// labels them so that they are owned by the bootstrap Kustomization.
+// Without --export-existing the resources are only reported, labeling
+// them would let the bootstrap Kustomization garbage collect them.
+func adoptExistingResources(ctx context.Context, kubeClient client.Client, repoDir, targetPath string, commit func(dir string) error) error {
+	logger.Actionf("looking for existing Flux resources")
+	existing, err := findExistingResources(ctx, kubeClient)
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		logger.Successf("no existing resources found")
+		return nil
+	}
+
+	if !bootstrapArgs.exportExisting {
+		for _, obj := range existing {
+			logger.Waitingf("%s/%s/%s is not managed by Flux", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		}
+		logger.Waitingf("%d existing resources left unmanaged, use --export-existing to adopt them", len(existing))
+		return nil
+	}
+
+	dir := path.Join(targetPath, existingResourcesDir)
+	for _, obj := range existing {
+		file := filepath.Join(repoDir, dir, obj.GetNamespace(),
+			fmt.Sprintf("%s-%s.yaml", strings.ToLower(obj.GetKind()), obj.GetName()))
+		if err := writeExistingResource(file, obj); err != nil {
+			return err
+		}
+	}
+	if err := commit(dir); err != nil {
+		return err
+	}
+	logger.Successf("%d existing resources exported to %s", len(existing), dir)
+
+	for _, obj := range existing {
+		patch := client.MergeFrom(obj.DeepCopy())
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[kustomizev1.GroupVersion.Group+"/name"] = rootArgs.namespace
+		labels[kustomizev1.GroupVersion.Group+"/namespace"] = rootArgs.namespace
+		obj.SetLabels(labels)
+		if err := kubeClient.Patch(ctx, &obj, patch); err != nil {
+			return fmt.Errorf("labeling %s/%s/%s failed: %w", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+	logger.Successf("existing resources adopted by %s Kustomization", rootArgs.namespace)
+	return nil
+}
+
+// writeExistingResource writes an object stripped of its status and of
+// the metadata set by the API server.
+func writeExistingResource(file string, obj unstructured.Unstructured) error {
+	obj = *obj.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "status")
+	for _, field := range []string{"managedFields", "uid", "resourceVersion", "generation", "creationTimestamp", "selfLink"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration")
+	if len(obj.GetAnnotations()) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+	}
+
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append([]byte("---\n"), data...), 0644)
+}
@@ -0,0 +1,480 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var bootstrapGitCmd = &cobra.Command{
+	Use:   "git",
+	Short: "Bootstrap toolkit components in a Git repository",
+	Long: `The bootstrap git command clones a Git repository over SSH or HTTPS and
+commits the toolkit components manifests to the specified branch.
+Then it configures the target cluster to synchronize with the repository.
+Unlike the provider specific commands, it doesn't create the repository nor the deploy keys
+through an API, which makes it suitable for any Git server.
+If the toolkit components are present on the cluster,
+the bootstrap command will perform an upgrade if needed.`,
+	Example: `  # Run bootstrap for a Git repository using an SSH private key
+  flux bootstrap git --url=ssh://git@example.com/org/fleet --private-key-file=./identity
+
+  # Run bootstrap for a Git repository using the keys loaded in the SSH agent,
+  # a deploy key is generated for the cluster and must be added to the repository
+  flux bootstrap git --url=ssh://git@example.com/org/fleet
+
+  # Run bootstrap for a Git repository using HTTPS basic authentication
+  flux bootstrap git --url=https://example.com/org/fleet --username=<user> --password=<password>
+
+  # Run bootstrap using an existing secret with the Git credentials for the cluster
+  flux bootstrap git --url=ssh://git@example.com/org/fleet --private-key-file=./identity --sync-secret-ref=fleet-auth
+`,
+	RunE: bootstrapGitCmdRun,
+}
+
+type gitFlags struct {
+	url            string
+	interval       time.Duration
+	path           flags.SafeRelativePath
+	username       string
+	password       string
+	privateKeyFile string
+	authorName     string
+	authorEmail    string
+}
+
+var gitArgs gitFlags
+
+func init() {
+	bootstrapGitCmd.Flags().StringVar(&gitArgs.url, "url", "", "Git repository URL, in the format ssh://<user>@<host>/<path> or https://<host>/<path>")
+	bootstrapGitCmd.Flags().DurationVar(&gitArgs.interval, "interval", time.Minute, "sync interval")
+	bootstrapGitCmd.Flags().Var(&gitArgs.path, "path", "path relative to the repository root, when specified the cluster sync will be scoped to this path")
+	bootstrapGitCmd.Flags().StringVarP(&gitArgs.username, "username", "u", "git", "basic authentication username for HTTPS URLs")
+	bootstrapGitCmd.Flags().StringVarP(&gitArgs.password, "password", "p", "", "basic authentication password for HTTPS URLs")
+	bootstrapGitCmd.Flags().StringVar(&gitArgs.privateKeyFile, "private-key-file", "", "path to an SSH private key used to access the repository, when not specified the SSH agent is used")
+	bootstrapGitCmd.Flags().StringVar(&gitArgs.authorName, "author-name", "flux", "author name for Git commits")
+	bootstrapGitCmd.Flags().StringVar(&gitArgs.authorEmail, "author-email", "", "author email for Git commits, defaults to flux@<host>")
+
+	bootstrapCmd.AddCommand(bootstrapGitCmd)
+}
+
+func bootstrapGitCmdRun(cmd *cobra.Command, args []string) (retErr error) {
+	status := newStatusFile(bootstrapArgs.statusFile, cmd.CommandPath())
+	defer func() { status.finish(retErr) }()
+
+	if gitArgs.url == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	u, err := url.Parse(gitArgs.url)
+	if err != nil {
+		return fmt.Errorf("git URL parse failed: %w", err)
+	}
+	if u.Scheme != "ssh" && u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("git URL scheme '%s' not supported, can be: ssh, http and https", u.Scheme)
+	}
+	if u.Scheme != "ssh" && gitArgs.privateKeyFile != "" {
+		return fmt.Errorf("--private-key-file can only be used with SSH URLs")
+	}
+
+	if err := bootstrapValidate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+
+	if err := validateSyncSecret(ctx, kubeClient, rootArgs.namespace); err != nil {
+		return err
+	}
+
+	usedPath, bootstrapPathDiffers := checkIfBootstrapPathDiffers(ctx, kubeClient, rootArgs.namespace, filepath.ToSlash(gitArgs.path.String()))
+
+	if bootstrapPathDiffers {
+		return fmt.Errorf("cluster already bootstrapped to %v path", usedPath)
+	}
+
+	tmpDir, err := ioutil.TempDir("", rootArgs.namespace)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// collect the SSH host key, it is used to verify the server
+	// when cloning and it is stored in the cluster sync secret
+	var hostKey []byte
+	if u.Scheme == "ssh" {
+		logger.Actionf("collecting preferred public key from SSH server")
+		hostKey, err = scanHostKey(ctx, u)
+		if err != nil {
+			return err
+		}
+		logger.Successf("collected public key from SSH server:\n%s", hostKey)
+	}
+
+	auth, err := genericGitAuth(u, hostKey, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	authorEmail := gitArgs.authorEmail
+	if authorEmail == "" {
+		authorEmail = "flux@" + u.Hostname()
+	}
+	repository := &genericRepository{
+		URL:         gitArgs.url,
+		Auth:        auth,
+		AuthorName:  gitArgs.authorName,
+		AuthorEmail: authorEmail,
+	}
+
+	// clone repository and checkout the branch
+	repoDir := filepath.Join(tmpDir, "repository")
+	logger.Actionf("cloning %s", gitArgs.url)
+	if err := repository.Checkout(ctx, bootstrapArgs.branch, repoDir); err != nil {
+		return err
+	}
+	logger.Successf("repository cloned")
+	status.phase(phaseRepositoryPrepared, "")
+
+	// generate install manifests
+	logger.Generatef("generating manifests")
+	skipNamespace := isForeignNamespace(ctx, kubeClient, rootArgs.namespace)
+	if skipNamespace {
+		logger.Actionf("using existing %s namespace", rootArgs.namespace)
+	}
+	installManifest, err := generateInstallManifests(gitArgs.path.String(), rootArgs.namespace, repoDir, bootstrapArgs.manifestsPath, skipNamespace)
+	if err != nil {
+		return err
+	}
+
+	// stage install manifests
+	changed, err := repository.Commit(path.Join(gitArgs.path.String(), rootArgs.namespace), "Add manifests")
+	if err != nil {
+		return err
+	}
+
+	// push install manifests
+	if changed {
+		if err := repository.Push(ctx, bootstrapArgs.branch); err != nil {
+			return err
+		}
+		logger.Successf("components manifests pushed")
+	} else {
+		logger.Successf("components are up to date")
+	}
+	status.phase(phaseManifestsCommitted, "")
+
+	// adopt the Flux resources applied outside of Git
+	if bootstrapArgs.recurseExisting {
+		err := adoptExistingResources(ctx, kubeClient, repoDir, gitArgs.path.String(), func(dir string) error {
+			changed, err := repository.Commit(dir, "Add existing resources")
+			if err != nil || !changed {
+				return err
+			}
+			return repository.Push(ctx, bootstrapArgs.branch)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// determine if repo synchronization is working
+	isInstall := shouldInstallManifests(ctx, kubeClient, rootArgs.namespace)
+
+	if isInstall {
+		// apply install manifests
+		logger.Actionf("installing components in %s namespace", rootArgs.namespace)
+		if err := applyInstallManifests(ctx, installManifest, bootstrapComponents()); err != nil {
+			return err
+		}
+		logger.Successf("install completed")
+		status.phase(phaseComponentsHealthy, "")
+	} else {
+		status.phase(phaseComponentsHealthy, "components already installed")
+	}
+
+	if bootstrapArgs.syncSecretRef != "" {
+		logger.Successf("using Git credentials from %s secret", bootstrapArgs.syncSecretRef)
+	} else if err := configureGenericGitSecret(ctx, kubeClient, u, hostKey); err != nil {
+		return err
+	}
+
+	status.phase(phaseKeyConfigured, "")
+
+	// configure repo synchronization
+	logger.Actionf("generating sync manifests")
+	syncManifests, err := generateSyncManifests(gitArgs.url, bootstrapArgs.branch, rootArgs.namespace, rootArgs.namespace, filepath.ToSlash(gitArgs.path.String()), repoDir, gitArgs.interval)
+	if err != nil {
+		return err
+	}
+
+	// commit and push manifests
+	if changed, err = repository.Commit(path.Join(gitArgs.path.String(), rootArgs.namespace), "Add manifests"); err != nil {
+		return err
+	} else if changed {
+		if err := repository.Push(ctx, bootstrapArgs.branch); err != nil {
+			return err
+		}
+		logger.Successf("sync manifests pushed")
+	}
+
+	// apply manifests and waiting for sync
+	logger.Actionf("applying sync manifests")
+	if err := applySyncManifests(ctx, kubeClient, rootArgs.namespace, rootArgs.namespace, syncManifests); err != nil {
+		return err
+	}
+	status.phase(phaseSyncVerified, "")
+
+	logger.Successf("bootstrap finished")
+	return nil
+}
+
+// genericGitAuth returns the credentials used by the CLI to clone and push,
+// SSH host keys are verified against the key collected from the server.
+func genericGitAuth(u *url.URL, hostKey []byte, tmpDir string) (transport.AuthMethod, error) {
+	if u.Scheme != "ssh" {
+		if gitArgs.password == "" {
+			return nil, nil
+		}
+		return &http.BasicAuth{
+			Username: gitArgs.username,
+			Password: gitArgs.password,
+		}, nil
+	}
+
+	knownHosts := filepath.Join(tmpDir, "known_hosts")
+	if err := ioutil.WriteFile(knownHosts, hostKey, 0600); err != nil {
+		return nil, err
+	}
+	hostKeyCallback, err := gitssh.NewKnownHostsCallback(knownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	user := u.User.Username()
+	if user == "" {
+		user = "git"
+	}
+
+	if gitArgs.privateKeyFile != "" {
+		auth, err := gitssh.NewPublicKeysFromFile(user, gitArgs.privateKeyFile, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key: %w", err)
+		}
+		auth.HostKeyCallback = hostKeyCallback
+		return auth, nil
+	}
+
+	auth, err := gitssh.NewSSHAgentAuth(user)
+	if err != nil {
+		return nil, fmt.Errorf("no private key file given and SSH agent unavailable: %w", err)
+	}
+	auth.HostKeyCallback = hostKeyCallback
+	return auth, nil
+}
+
+// configureGenericGitSecret creates the secret used by source-controller to
+// access the repository. Without a private key or password, a deploy key is
+// generated and the user is asked to add it to the repository.
+func configureGenericGitSecret(ctx context.Context, kubeClient client.Client, u *url.URL, hostKey []byte) error {
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rootArgs.namespace,
+			Namespace: rootArgs.namespace,
+		},
+	}
+
+	switch {
+	case u.Scheme != "ssh" && gitArgs.password != "":
+		secret.StringData = map[string]string{
+			"username": gitArgs.username,
+			"password": gitArgs.password,
+		}
+	case u.Scheme != "ssh":
+		return nil
+	case gitArgs.privateKeyFile != "":
+		identity, err := ioutil.ReadFile(gitArgs.privateKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read private key: %w", err)
+		}
+		secret.StringData = map[string]string{
+			"identity":    string(identity),
+			"known_hosts": string(hostKey),
+		}
+	default:
+		if !shouldCreateDeployKey(ctx, kubeClient, rootArgs.namespace) {
+			return nil
+		}
+		logger.Actionf("configuring deploy key")
+		key, err := generateDeployKey(ctx, kubeClient, u, rootArgs.namespace)
+		if err != nil {
+			return fmt.Errorf("generating deploy key failed: %w", err)
+		}
+		logger.Successf("deploy key: %s", key)
+		prompt := promptui.Prompt{
+			Label:     "Have you added the deploy key to your repository",
+			IsConfirm: true,
+		}
+		if _, err := prompt.Run(); err != nil {
+			return fmt.Errorf("aborting")
+		}
+		return nil
+	}
+
+	if err := upsertSecret(ctx, kubeClient, secret); err != nil {
+		return err
+	}
+	logger.Successf("sync secret configured")
+	return nil
+}
+
+// genericRepository is a Git repository accessed without a provider API,
+// it mirrors the operations of the provider repositories.
+type genericRepository struct {
+	URL         string
+	Auth        transport.AuthMethod
+	AuthorName  string
+	AuthorEmail string
+
+	repo *gogit.Repository
+}
+
+// Checkout clones the branch at the specified path, an empty remote
+// repository is initialised with the branch instead.
+func (r *genericRepository) Checkout(ctx context.Context, branch, path string) error {
+	repo, err := gogit.PlainCloneContext(ctx, path, false, &gogit.CloneOptions{
+		URL:           r.URL,
+		Auth:          r.Auth,
+		RemoteName:    gogit.DefaultRemoteName,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		SingleBranch:  true,
+		Tags:          gogit.NoTags,
+	})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		repo, err = r.init(branch, path)
+	}
+	if err != nil {
+		return fmt.Errorf("git clone error: %w", err)
+	}
+
+	r.repo = repo
+	return nil
+}
+
+func (r *genericRepository) init(branch, path string) (*gogit.Repository, error) {
+	repo, err := gogit.PlainInit(path, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{
+		Name: gogit.DefaultRemoteName,
+		URLs: []string{r.URL},
+	}); err != nil {
+		return nil, err
+	}
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch))
+	if err := repo.Storer.SetReference(head); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// Commit changes for the specified path, returns false if no changes are detected
+func (r *genericRepository) Commit(path, message string) (bool, error) {
+	if r.repo == nil {
+		return false, fmt.Errorf("repository hasn't been cloned")
+	}
+
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := w.Add(path); err != nil {
+		return false, err
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		return false, err
+	}
+	if status.IsClean() {
+		return false, nil
+	}
+
+	if _, err := w.Commit(message, &gogit.CommitOptions{
+		Author: &object.Signature{
+			Name:  r.AuthorName,
+			Email: r.AuthorEmail,
+			When:  time.Now(),
+		},
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Push the branch to origin
+func (r *genericRepository) Push(ctx context.Context, branch string) error {
+	if r.repo == nil {
+		return fmt.Errorf("repository hasn't been cloned")
+	}
+
+	ref := plumbing.NewBranchReferenceName(branch)
+	err := r.repo.PushContext(ctx, &gogit.PushOptions{
+		RemoteName: gogit.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:%s", ref, ref))},
+		Auth:       r.Auth,
+	})
+	if err != nil {
+		return fmt.Errorf("git push error: %w", err)
+	}
+	return nil
+}
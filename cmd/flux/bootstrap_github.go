@@ -67,6 +67,10 @@ the bootstrap command will perform an upgrade if needed.`,
 
   # Run bootstrap for a an existing repository with a branch named main
   flux bootstrap github --owner=<organization> --repository=<repo name> --branch=main
+
+  # Run bootstrap with an existing Git credentials secret and an IAM role for the controllers
+  flux bootstrap github --owner=<organization> --repository=<repo name> --sync-secret-ref=<secret name> \
+    --sa-annotation=eks.amazonaws.com/role-arn=<role arn>
 `,
 	RunE: bootstrapGitHubCmdRun,
 }
@@ -107,7 +111,10 @@ func init() {
 	bootstrapCmd.AddCommand(bootstrapGitHubCmd)
 }
 
-func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
+func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) (retErr error) {
+	status := newStatusFile(bootstrapArgs.statusFile, cmd.CommandPath())
+	defer func() { status.finish(retErr) }()
+
 	ghToken := os.Getenv(git.GitHubTokenName)
 	if ghToken == "" {
 		return fmt.Errorf("%s environment variable not found", git.GitHubTokenName)
@@ -117,14 +124,18 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
 
+	if err := validateSyncSecret(ctx, kubeClient, rootArgs.namespace); err != nil {
+		return err
+	}
+
 	usedPath, bootstrapPathDiffers := checkIfBootstrapPathDiffers(ctx, kubeClient, rootArgs.namespace, filepath.ToSlash(githubArgs.path.String()))
 
 	if bootstrapPathDiffers {
@@ -167,6 +178,11 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	if changed {
 		logger.Successf("repository created")
+		defer func() {
+			if cmd.Context().Err() != nil {
+				rollbackRepository(provider, repository, status)
+			}
+		}()
 	}
 
 	withErrors := false
@@ -187,10 +203,15 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	logger.Successf("repository cloned")
+	status.phase(phaseRepositoryPrepared, "")
 
 	// generate install manifests
 	logger.Generatef("generating manifests")
-	installManifest, err := generateInstallManifests(githubArgs.path.String(), rootArgs.namespace, tmpDir, bootstrapArgs.manifestsPath)
+	skipNamespace := isForeignNamespace(ctx, kubeClient, rootArgs.namespace)
+	if skipNamespace {
+		logger.Actionf("using existing %s namespace", rootArgs.namespace)
+	}
+	installManifest, err := generateInstallManifests(githubArgs.path.String(), rootArgs.namespace, tmpDir, bootstrapArgs.manifestsPath, skipNamespace)
 	if err != nil {
 		return err
 	}
@@ -210,6 +231,21 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 	} else {
 		logger.Successf("components are up to date")
 	}
+	status.phase(phaseManifestsCommitted, "")
+
+	// adopt the Flux resources applied outside of Git
+	if bootstrapArgs.recurseExisting {
+		err := adoptExistingResources(ctx, kubeClient, tmpDir, githubArgs.path.String(), func(dir string) error {
+			changed, err := repository.Commit(ctx, dir, "Add existing resources")
+			if err != nil || !changed {
+				return err
+			}
+			return repository.Push(ctx)
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	// determine if repo synchronization is working
 	isInstall := shouldInstallManifests(ctx, kubeClient, rootArgs.namespace)
@@ -221,11 +257,20 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		logger.Successf("install completed")
+		status.phase(phaseComponentsHealthy, "")
+	} else {
+		status.phase(phaseComponentsHealthy, "components already installed")
 	}
 
 	repoURL := repository.GetURL()
 
-	if bootstrapArgs.tokenAuth {
+	if bootstrapArgs.syncSecretRef != "" {
+		// use the Git credentials from the existing secret
+		if !bootstrapArgs.tokenAuth {
+			repoURL = repository.GetSSH()
+		}
+		logger.Successf("using Git credentials from %s secret", bootstrapArgs.syncSecretRef)
+	} else if bootstrapArgs.tokenAuth {
 		// setup HTTPS token auth
 		secret := corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
@@ -268,6 +313,8 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	status.phase(phaseKeyConfigured, "")
+
 	// configure repo synchronization
 	logger.Actionf("generating sync manifests")
 	syncManifests, err := generateSyncManifests(repoURL, bootstrapArgs.branch, rootArgs.namespace, rootArgs.namespace, filepath.ToSlash(githubArgs.path.String()), tmpDir, githubArgs.interval)
@@ -290,6 +337,7 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 	if err := applySyncManifests(ctx, kubeClient, rootArgs.namespace, rootArgs.namespace, syncManifests); err != nil {
 		return err
 	}
+	status.phase(phaseSyncVerified, "")
 
 	if withErrors {
 		return fmt.Errorf("bootstrap completed with errors")
@@ -99,7 +99,10 @@ func init() {
 	bootstrapCmd.AddCommand(bootstrapGitLabCmd)
 }
 
-func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
+func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) (retErr error) {
+	status := newStatusFile(bootstrapArgs.statusFile, cmd.CommandPath())
+	defer func() { status.finish(retErr) }()
+
 	glToken := os.Getenv(git.GitLabTokenName)
 	if glToken == "" {
 		return fmt.Errorf("%s environment variable not found", git.GitLabTokenName)
@@ -117,14 +120,18 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
 
+	if err := validateSyncSecret(ctx, kubeClient, rootArgs.namespace); err != nil {
+		return err
+	}
+
 	usedPath, bootstrapPathDiffers := checkIfBootstrapPathDiffers(ctx, kubeClient, rootArgs.namespace, filepath.ToSlash(gitlabArgs.path.String()))
 
 	if bootstrapPathDiffers {
@@ -159,6 +166,11 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	if changed {
 		logger.Successf("repository created")
+		defer func() {
+			if cmd.Context().Err() != nil {
+				rollbackRepository(provider, repository, status)
+			}
+		}()
 	}
 
 	// clone repository and checkout the master branch
@@ -166,10 +178,15 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	logger.Successf("repository cloned")
+	status.phase(phaseRepositoryPrepared, "")
 
 	// generate install manifests
 	logger.Generatef("generating manifests")
-	installManifest, err := generateInstallManifests(gitlabArgs.path.String(), rootArgs.namespace, tmpDir, bootstrapArgs.manifestsPath)
+	skipNamespace := isForeignNamespace(ctx, kubeClient, rootArgs.namespace)
+	if skipNamespace {
+		logger.Actionf("using existing %s namespace", rootArgs.namespace)
+	}
+	installManifest, err := generateInstallManifests(gitlabArgs.path.String(), rootArgs.namespace, tmpDir, bootstrapArgs.manifestsPath, skipNamespace)
 	if err != nil {
 		return err
 	}
@@ -189,6 +206,21 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 	} else {
 		logger.Successf("components are up to date")
 	}
+	status.phase(phaseManifestsCommitted, "")
+
+	// adopt the Flux resources applied outside of Git
+	if bootstrapArgs.recurseExisting {
+		err := adoptExistingResources(ctx, kubeClient, tmpDir, gitlabArgs.path.String(), func(dir string) error {
+			changed, err := repository.Commit(ctx, dir, "Add existing resources")
+			if err != nil || !changed {
+				return err
+			}
+			return repository.Push(ctx)
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	// determine if repo synchronization is working
 	isInstall := shouldInstallManifests(ctx, kubeClient, rootArgs.namespace)
@@ -200,11 +232,20 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		logger.Successf("install completed")
+		status.phase(phaseComponentsHealthy, "")
+	} else {
+		status.phase(phaseComponentsHealthy, "components already installed")
 	}
 
 	repoURL := repository.GetURL()
 
-	if bootstrapArgs.tokenAuth {
+	if bootstrapArgs.syncSecretRef != "" {
+		// use the Git credentials from the existing secret
+		if !bootstrapArgs.tokenAuth {
+			repoURL = repository.GetSSH()
+		}
+		logger.Successf("using Git credentials from %s secret", bootstrapArgs.syncSecretRef)
+	} else if bootstrapArgs.tokenAuth {
 		// setup HTTPS token auth
 		secret := corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
@@ -247,6 +288,8 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	status.phase(phaseKeyConfigured, "")
+
 	// configure repo synchronization
 	logger.Actionf("generating sync manifests")
 	syncManifests, err := generateSyncManifests(repoURL, bootstrapArgs.branch, rootArgs.namespace, rootArgs.namespace, filepath.ToSlash(gitlabArgs.path.String()), tmpDir, gitlabArgs.interval)
@@ -269,6 +312,7 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 	if err := applySyncManifests(ctx, kubeClient, rootArgs.namespace, rootArgs.namespace, syncManifests); err != nil {
 		return err
 	}
+	status.phase(phaseSyncVerified, "")
 
 	logger.Successf("bootstrap finished")
 	return nil
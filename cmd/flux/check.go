@@ -40,13 +40,21 @@ the local environment is configured correctly and if the installed components ar
 
   # Run installation checks
   flux check
+
+  # Run the checks of a tenant with permissions on its namespace only
+  flux check --namespaced --namespace=team-a
+
+  # Print the migration steps required to upgrade Flux to v2.0
+  flux check --upgrade-plan=v2.0.0
 `,
 	RunE: runCheckCmd,
 }
 
 type checkFlags struct {
-	pre        bool
-	components []string
+	pre         bool
+	namespaced  bool
+	components  []string
+	upgradePlan string
 }
 
 type kubectlVersion struct {
@@ -58,13 +66,18 @@ var checkArgs checkFlags
 func init() {
 	checkCmd.Flags().BoolVarP(&checkArgs.pre, "pre", "", false,
 		"only run pre-installation checks")
+	checkCmd.Flags().BoolVar(&checkArgs.namespaced, "namespaced", false,
+		"only run the checks which require permissions on the namespace, "+
+			"verifying that Flux resources can be created in it and that the controllers reconcile them")
 	checkCmd.Flags().StringSliceVar(&checkArgs.components, "components", rootArgs.defaults.Components,
 		"list of components, accepts comma-separated values")
+	checkCmd.Flags().StringVar(&checkArgs.upgradePlan, "upgrade-plan", "",
+		"analyze the cluster for the changes introduced up to the given Flux version and print the migration steps")
 	rootCmd.AddCommand(checkCmd)
 }
 
 func runCheckCmd(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
 	logger.Actionf("checking prerequisites")
@@ -86,8 +99,29 @@ func runCheckCmd(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if checkArgs.namespaced {
+		if !namespaceCheck(ctx) {
+			checkFailed = true
+		}
+		if checkFailed {
+			os.Exit(1)
+		}
+		logger.Successf("namespace checks passed")
+		return nil
+	}
+
+	if checkArgs.upgradePlan != "" {
+		if !upgradePlanCheck(ctx, checkArgs.upgradePlan) {
+			checkFailed = true
+		}
+		if checkFailed {
+			os.Exit(1)
+		}
+		return nil
+	}
+
 	logger.Actionf("checking controllers")
-	if !componentsCheck() {
+	if !componentsCheck(cmd.Context()) {
 		checkFailed = true
 	}
 	if checkFailed {
@@ -134,7 +168,7 @@ func kubectlCheck(ctx context.Context, version string) bool {
 }
 
 func kubernetesCheck(version string) bool {
-	cfg, err := utils.KubeConfig(rootArgs.kubeconfig, rootArgs.kubecontext)
+	cfg, err := utils.KubeConfig(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
 		return false
@@ -168,8 +202,8 @@ func kubernetesCheck(version string) bool {
 	return true
 }
 
-func componentsCheck() bool {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+func componentsCheck(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, rootArgs.timeout)
 	defer cancel()
 
 	ok := true
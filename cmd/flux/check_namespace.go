@@ -0,0 +1,163 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+// namespacedCheckKinds are the kinds a tenant is expected to manage,
+// grouped by the controller which reconciles them. The notification
+// kinds have no status.observedGeneration, their reconciliation is told
+// by the Ready condition instead.
+var namespacedCheckKinds = []struct {
+	controller         string
+	resource           schema.GroupVersionResource
+	kind               string
+	observedGeneration bool
+}{
+	{"source-controller", sourcev1.GroupVersion.WithResource("gitrepositories"), sourcev1.GitRepositoryKind, true},
+	{"source-controller", sourcev1.GroupVersion.WithResource("helmrepositories"), sourcev1.HelmRepositoryKind, true},
+	{"source-controller", sourcev1.GroupVersion.WithResource("buckets"), sourcev1.BucketKind, true},
+	{"kustomize-controller", kustomizev1.GroupVersion.WithResource("kustomizations"), kustomizev1.KustomizationKind, true},
+	{"helm-controller", helmv2.GroupVersion.WithResource("helmreleases"), helmv2.HelmReleaseKind, true},
+	{"notification-controller", notificationv1.GroupVersion.WithResource("alerts"), "Alert", false},
+	{"notification-controller", notificationv1.GroupVersion.WithResource("providers"), "Provider", false},
+	{"notification-controller", notificationv1.GroupVersion.WithResource("receivers"), "Receiver", false},
+}
+
+// namespaceCheck verifies, with namespace level permissions only, that the
+// Flux resources can be created in the namespace and that the controllers
+// reconcile the existing ones.
+func namespaceCheck(ctx context.Context) bool {
+	cfg, err := utils.KubeConfig(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
+		return false
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
+		return false
+	}
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
+		return false
+	}
+
+	ok := true
+	logger.Actionf("checking permissions in %s namespace", rootArgs.namespace)
+	for _, k := range namespacedCheckKinds {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: rootArgs.namespace,
+					Verb:      "create",
+					Group:     k.resource.Group,
+					Resource:  k.resource.Resource,
+				},
+			},
+		}
+		result, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		switch {
+		case err != nil:
+			logger.Failuref("%s: access review failed: %s", k.kind, err.Error())
+			ok = false
+		case result.Status.Allowed:
+			logger.Successf("%s can be created", k.kind)
+		default:
+			logger.Failuref("%s can't be created", k.kind)
+			ok = false
+		}
+	}
+
+	logger.Actionf("checking controllers in %s namespace", rootArgs.namespace)
+	controllers := []string{}
+	observed := map[string]int{}
+	pending := map[string]int{}
+	for _, k := range namespacedCheckKinds {
+		if _, seen := observed[k.controller]; !seen {
+			controllers = append(controllers, k.controller)
+			observed[k.controller] = 0
+		}
+		var list unstructured.UnstructuredList
+		list.SetGroupVersionKind(k.resource.GroupVersion().WithKind(k.kind + "List"))
+		if err := kubeClient.List(ctx, &list, client.InNamespace(rootArgs.namespace)); err != nil {
+			if apierrors.IsForbidden(err) {
+				logger.Waitingf("%s: listing is not allowed, skipping", k.kind)
+				continue
+			}
+			logger.Failuref("%s: %s", k.kind, err.Error())
+			ok = false
+			continue
+		}
+		for _, item := range list.Items {
+			if isObjectReconciled(item, k.observedGeneration) {
+				observed[k.controller]++
+			} else {
+				pending[k.controller]++
+			}
+		}
+	}
+	for _, controller := range controllers {
+		switch {
+		case observed[controller] > 0:
+			logger.Successf("%s is reconciling objects in %s namespace", controller, rootArgs.namespace)
+		case pending[controller] > 0:
+			logger.Failuref("%s has not reconciled any of the %d objects in %s namespace",
+				controller, pending[controller], rootArgs.namespace)
+			ok = false
+		default:
+			logger.Waitingf("%s: no objects in %s namespace, unable to verify", controller, rootArgs.namespace)
+		}
+	}
+	return ok
+}
+
+// isObjectReconciled tells if the controller has handled the current
+// state of an object, from its observed generation when the kind has one,
+// or else from the presence of a Ready condition.
+func isObjectReconciled(obj unstructured.Unstructured, observedGeneration bool) bool {
+	if observedGeneration {
+		generation, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+		return generation > 0 && generation == obj.GetGeneration()
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		if m, ok := c.(map[string]interface{}); ok && m["type"] == meta.ReadyCondition {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestIsObjectReconciled(t *testing.T) {
+	tests := []struct {
+		name               string
+		obj                map[string]interface{}
+		observedGeneration bool
+		expect             bool
+	}{
+		{
+			name: "observed generation matches",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(2)},
+				"status":   map[string]interface{}{"observedGeneration": int64(2)},
+			},
+			observedGeneration: true,
+			expect:             true,
+		},
+		{
+			name: "observed generation behind",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(3)},
+				"status":   map[string]interface{}{"observedGeneration": int64(2)},
+			},
+			observedGeneration: true,
+			expect:             false,
+		},
+		{
+			name: "alert with ready condition",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(1)},
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": "False"},
+				}},
+			},
+			expect: true,
+		},
+		{
+			name: "alert without conditions",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(1)},
+			},
+			expect: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := unstructured.Unstructured{Object: tt.obj}
+			if got := isObjectReconciled(obj, tt.observedGeneration); got != tt.expect {
+				t.Errorf("isObjectReconciled() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
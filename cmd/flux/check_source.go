@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var checkSourceCmd = &cobra.Command{
+	Use:   "source",
+	Short: "Check connectivity to sources",
+	Long: `The check source sub-commands connect to the upstream of a source from the CLI machine,
+using the credentials referenced by the source, and report authentication, TLS and network failures.`,
+}
+
+func init() {
+	checkCmd.AddCommand(checkSourceCmd)
+}
+
+// errCredentialsRejected is returned when an upstream answers with a
+// 401 or 403 status code.
+var errCredentialsRejected = errors.New("credentials rejected by upstream")
+
+// connectionFailure describes why a source upstream could not be reached.
+func connectionFailure(err error) string {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostnameErr      x509.HostnameError
+		certInvalid      x509.CertificateInvalidError
+		recordHeader     tls.RecordHeaderError
+		netErr           net.Error
+		keyErr           *knownhosts.KeyError
+		revokedErr       *knownhosts.RevokedError
+	)
+	switch {
+	case errors.Is(err, errCredentialsRejected),
+		errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed):
+		return "authentication failed"
+	case errors.As(err, &keyErr), errors.As(err, &revokedErr):
+		return "host key verification failed"
+	case errors.As(err, &unknownAuthority),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &certInvalid),
+		errors.As(err, &recordHeader):
+		return "TLS verification failed"
+	case errors.As(err, &netErr):
+		return "network error"
+	case errors.Is(err, transport.ErrRepositoryNotFound):
+		return "repository not found"
+	default:
+		return "connection failed"
+	}
+}
+
+// reportConnection logs the outcome of a source connectivity check.
+func reportConnection(kind, name string, err error) error {
+	if err != nil {
+		logger.Failuref("%s: %v", connectionFailure(err), err)
+		return fmt.Errorf("%s '%s' upstream check failed", kind, name)
+	}
+	logger.Successf("%s '%s' upstream is reachable", kind, name)
+	return nil
+}
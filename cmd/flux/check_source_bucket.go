@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var checkSourceBucketCmd = &cobra.Command{
+	Use:   "bucket [name]",
+	Short: "Check connectivity to a Bucket endpoint",
+	Long: `The check source bucket command sends a signed request for the bucket to its endpoint,
+using the credentials from the secret referenced by the source.`,
+	Example: `  # Check that the bucket of a Bucket source can be reached
+  flux check source bucket podinfo
+`,
+	RunE: checkSourceBucketCmdRun,
+}
+
+func init() {
+	checkSourceCmd.AddCommand(checkSourceBucketCmd)
+}
+
+func checkSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("Bucket name is required")
+	}
+	name := args[0]
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	var bucket sourcev1.Bucket
+	if err := kubeClient.Get(ctx, namespacedName, &bucket); err != nil {
+		return err
+	}
+
+	logger.Actionf("requesting bucket %s from %s", bucket.Spec.BucketName, bucket.Spec.Endpoint)
+	status, err := requestBucketEndpoint(ctx, kubeClient, bucket)
+	switch status {
+	case "Healthy":
+	case "CredentialsRejected":
+		err = errCredentialsRejected
+	case "SecretNotFound", "SecretForbidden", "BucketNotFound":
+		err = fmt.Errorf("%s", status)
+	default:
+		err = fmt.Errorf("%s: %w", status, err)
+	}
+	return reportConnection("Bucket", name, err)
+}
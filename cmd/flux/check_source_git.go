@@ -0,0 +1,282 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	nethttp "net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var checkSourceGitCmd = &cobra.Command{
+	Use:   "git [name]",
+	Short: "Check connectivity to a GitRepository upstream",
+	Long: `The check source git command lists the remote references of a GitRepository,
+using the credentials from the secret referenced by the source.`,
+	Example: `  # Check that the repository of a GitRepository can be reached
+  flux check source git podinfo
+`,
+	RunE: checkSourceGitCmdRun,
+}
+
+func init() {
+	checkSourceCmd.AddCommand(checkSourceGitCmd)
+}
+
+func checkSourceGitCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("GitRepository name is required")
+	}
+	name := args[0]
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	var repository sourcev1.GitRepository
+	if err := kubeClient.Get(ctx, namespacedName, &repository); err != nil {
+		return err
+	}
+
+	var secret *corev1.Secret
+	if repository.Spec.SecretRef != nil {
+		secret = &corev1.Secret{}
+		secretName := types.NamespacedName{
+			Namespace: rootArgs.namespace,
+			Name:      repository.Spec.SecretRef.Name,
+		}
+		if err := kubeClient.Get(ctx, secretName, secret); err != nil {
+			return fmt.Errorf("unable to read secret '%s': %w", repository.Spec.SecretRef.Name, err)
+		}
+	}
+
+	tmpDir, err := ioutil.TempDir("", name)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	auth, err := gitSecretAuth(repository.Spec.URL, secret, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	logger.Actionf("listing references of %s", repository.Spec.URL)
+	return reportConnection("GitRepository", name, listGitReferences(ctx, repository.Spec.URL, auth))
+}
+
+// gitSecretAuth returns the go-git credentials from a secret in the format
+// used by source-controller for GitRepositories.
+func gitSecretAuth(repositoryURL string, secret *corev1.Secret, tmpDir string) (transport.AuthMethod, error) {
+	if secret == nil {
+		return nil, nil
+	}
+
+	u, err := url.Parse(repositoryURL)
+	if err != nil {
+		return nil, fmt.Errorf("git URL parse failed: %w", err)
+	}
+
+	if u.Scheme != "ssh" {
+		if _, ok := secret.Data["username"]; !ok {
+			return nil, nil
+		}
+		return &http.BasicAuth{
+			Username: string(secret.Data["username"]),
+			Password: string(secret.Data["password"]),
+		}, nil
+	}
+
+	user := u.User.Username()
+	if user == "" {
+		user = "git"
+	}
+	auth, err := gitssh.NewPublicKeys(user, secret.Data["identity"], string(secret.Data["password"]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity in secret '%s': %w", secret.Name, err)
+	}
+	knownHosts := filepath.Join(tmpDir, "known_hosts")
+	if err := ioutil.WriteFile(knownHosts, secret.Data["known_hosts"], 0600); err != nil {
+		return nil, err
+	}
+	if auth.HostKeyCallback, err = gitssh.NewKnownHostsCallback(knownHosts); err != nil {
+		return nil, fmt.Errorf("invalid known_hosts in secret '%s': %w", secret.Name, err)
+	}
+	return auth, nil
+}
+
+// listGitReferences runs the equivalent of git ls-remote, bounded by ctx.
+// The go-git transports don't take a context: the HTTP requests get the
+// deadline of ctx as client timeout, and the SSH connection is opened here
+// so that the dial, the handshake and the upload-pack exchange all stop
+// with ctx.
+func listGitReferences(ctx context.Context, repositoryURL string, auth transport.AuthMethod) error {
+	endpoint, err := transport.NewEndpoint(repositoryURL)
+	if err != nil {
+		return err
+	}
+
+	switch endpoint.Protocol {
+	case "http", "https":
+		var timeout time.Duration
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+		cli := http.NewClient(&nethttp.Client{Timeout: timeout})
+		session, err := cli.NewUploadPackSession(endpoint, auth)
+		if err != nil {
+			return err
+		}
+		defer session.Close()
+		_, err = session.AdvertisedReferences()
+		return err
+	case "ssh":
+		if auth == nil {
+			if auth, err = gitssh.DefaultAuthBuilder(endpoint.User); err != nil {
+				return err
+			}
+		}
+		sshAuth, ok := auth.(gitssh.AuthMethod)
+		if !ok {
+			return fmt.Errorf("invalid auth method for SSH: %s", auth.Name())
+		}
+		return listSSHReferences(ctx, endpoint, sshAuth)
+	default:
+		return fmt.Errorf("unsupported protocol '%s'", endpoint.Protocol)
+	}
+}
+
+// listSSHReferences reads the references advertised by git-upload-pack
+// over an SSH connection that is closed once ctx is done.
+func listSSHReferences(ctx context.Context, endpoint *transport.Endpoint, auth gitssh.AuthMethod) error {
+	config, err := auth.ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	// The SSH handshake errors are only text, keep the outcome of the host
+	// key verification to tell the failures apart.
+	var hostKeyAccepted bool
+	var hostKeyErr error
+	if callback := config.HostKeyCallback; callback != nil {
+		config.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if err := callback(hostname, remote, key); err != nil {
+				hostKeyErr = err
+				return err
+			}
+			hostKeyAccepted = true
+			return nil
+		}
+	}
+
+	port := endpoint.Port
+	if port <= 0 {
+		port = gitssh.DefaultPort
+	}
+	addr := net.JoinHostPort(endpoint.Host, strconv.Itoa(port))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		var netErr net.Error
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case hostKeyErr != nil:
+			return fmt.Errorf("host key verification failed: %w", hostKeyErr)
+		case hostKeyAccepted && !errors.As(err, &netErr):
+			// Once the host key is accepted, the handshake can only fail on
+			// the user authentication or on the network.
+			return fmt.Errorf("%w: %v", transport.ErrAuthenticationRequired, err)
+		}
+		return err
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := session.Start(fmt.Sprintf("git-upload-pack '%s'", endpoint.Path)); err != nil {
+		return err
+	}
+
+	if err := packp.NewAdvRefs().Decode(stdout); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("git-upload-pack failed: %s", msg)
+		}
+		return err
+	}
+	return nil
+}
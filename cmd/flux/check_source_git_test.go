@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestListGitReferencesTimeout(t *testing.T) {
+	// A server that accepts the connection and never starts the handshake.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	auth := &gitssh.Password{
+		User:     "git",
+		Password: "secret",
+		HostKeyCallbackHelper: gitssh.HostKeyCallbackHelper{
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- listGitReferences(ctx, fmt.Sprintf("ssh://git@%s/org/repo.git", listener.Addr()), auth)
+	}()
+	select {
+	case err := <-errc:
+		if err != context.DeadlineExceeded {
+			t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("listGitReferences did not return after the context deadline")
+	}
+}
+
+func TestConnectionFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"authentication", fmt.Errorf("%w: ssh: unable to authenticate", transport.ErrAuthenticationRequired), "authentication failed"},
+		{"authorization", transport.ErrAuthorizationFailed, "authentication failed"},
+		{"host key", fmt.Errorf("host key verification failed: %w", &knownhosts.KeyError{}), "host key verification failed"},
+		{"unknown authority", fmt.Errorf("Get: %w", x509.UnknownAuthorityError{}), "TLS verification failed"},
+		{"repository not found", transport.ErrRepositoryNotFound, "repository not found"},
+		{"other", fmt.Errorf("unable to authenticate"), "connection failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := connectionFailure(tt.err); got != tt.want {
+				t.Errorf("connectionFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
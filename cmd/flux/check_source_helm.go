@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var checkSourceHelmCmd = &cobra.Command{
+	Use:   "helm [name]",
+	Short: "Check connectivity to a HelmRepository",
+	Long: `The check source helm command downloads the index of a HelmRepository,
+using the credentials and certificates from the secret referenced by the source.`,
+	Example: `  # Check that the index of a HelmRepository can be downloaded
+  flux check source helm bitnami
+`,
+	RunE: checkSourceHelmCmdRun,
+}
+
+func init() {
+	checkSourceCmd.AddCommand(checkSourceHelmCmd)
+}
+
+func checkSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("HelmRepository name is required")
+	}
+	name := args[0]
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	var repository sourcev1.HelmRepository
+	if err := kubeClient.Get(ctx, namespacedName, &repository); err != nil {
+		return err
+	}
+
+	var secret corev1.Secret
+	if repository.Spec.SecretRef != nil {
+		secretName := types.NamespacedName{
+			Namespace: rootArgs.namespace,
+			Name:      repository.Spec.SecretRef.Name,
+		}
+		if err := kubeClient.Get(ctx, secretName, &secret); err != nil {
+			return fmt.Errorf("unable to read secret '%s': %w", repository.Spec.SecretRef.Name, err)
+		}
+	}
+
+	indexURL := strings.TrimSuffix(repository.Spec.URL, "/") + "/index.yaml"
+	logger.Actionf("downloading %s", indexURL)
+	return reportConnection("HelmRepository", name, fetchHelmIndex(ctx, indexURL, secret))
+}
+
+// fetchHelmIndex requests the repository index with the basic auth and TLS
+// settings from a secret in the format used by source-controller.
+func fetchHelmIndex(ctx context.Context, indexURL string, secret corev1.Secret) error {
+	tlsConfig := &tls.Config{}
+	if certFile, keyFile := secret.Data["certFile"], secret.Data["keyFile"]; len(certFile) > 0 && len(keyFile) > 0 {
+		cert, err := tls.X509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("invalid certificate in secret '%s': %w", secret.Name, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if caFile := secret.Data["caFile"]; len(caFile) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caFile) {
+			return fmt.Errorf("invalid CA certificate in secret '%s'", secret.Name)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+	if err != nil {
+		return err
+	}
+	if username, ok := secret.Data["username"]; ok {
+		req.SetBasicAuth(string(username), string(secret.Data["password"]))
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return errCredentialsRejected
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("index download failed: %s", resp.Status)
+	}
+	return nil
+}
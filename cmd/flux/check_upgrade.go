@@ -0,0 +1,254 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+// upgradeRule describes a change introduced by a Flux release which
+// requires an action from the cluster operator before upgrading.
+type upgradeRule struct {
+	// since is the Flux release which introduces the change.
+	since string
+	group string
+	kind  string
+	// plural is the resource name of the kind, used to look up its CRD.
+	plural string
+	// storedVersion matches the CRDs which still have objects stored
+	// in an API version removed by the release.
+	storedVersion string
+	// field matches the objects which set a field removed or changed
+	// by the release.
+	field []string
+	// missing inverts the field match, for fields that become required.
+	missing bool
+	// step is the migration action, formatted with the matched objects.
+	step string
+}
+
+// upgradeRules are collected from the Flux release notes, ordered by release.
+var upgradeRules = []upgradeRule{
+	{
+		since: "0.26.0", group: "kustomize.toolkit.fluxcd.io", kind: "Kustomization", plural: "kustomizations",
+		field: []string{"spec", "prune"}, missing: true,
+		step: "set spec.prune explicitly on %s, the field is required from v1beta2",
+	},
+	{
+		since: "0.26.0", group: "kustomize.toolkit.fluxcd.io", kind: "Kustomization", plural: "kustomizations",
+		field: []string{"spec", "validation"},
+		step:  "remove spec.validation from %s, server-side apply validates all objects",
+	},
+	{
+		since: "2.0.0", group: "kustomize.toolkit.fluxcd.io", kind: "Kustomization", plural: "kustomizations",
+		field: []string{"spec", "patchesStrategicMerge"},
+		step:  "move spec.patchesStrategicMerge of %s to spec.patches",
+	},
+	{
+		since: "2.0.0", group: "kustomize.toolkit.fluxcd.io", kind: "Kustomization", plural: "kustomizations",
+		field: []string{"spec", "patchesJson6902"},
+		step:  "move spec.patchesJson6902 of %s to spec.patches",
+	},
+	{
+		since: "2.0.0", group: "source.toolkit.fluxcd.io", kind: "GitRepository", plural: "gitrepositories",
+		field: []string{"spec", "gitImplementation"},
+		step:  "remove spec.gitImplementation from %s, only go-git is supported",
+	},
+	{
+		since: "2.3.0", group: "source.toolkit.fluxcd.io", kind: "HelmChart", plural: "helmcharts",
+		field: []string{"spec", "valuesFile"},
+		step:  "replace spec.valuesFile of %s with spec.valuesFiles",
+	},
+	{
+		since: "2.3.0", group: "source.toolkit.fluxcd.io", kind: "GitRepository", plural: "gitrepositories",
+		storedVersion: "v1beta1",
+		step:          "rewrite the %s objects stored as v1beta1 and remove v1beta1 from the CRD status.storedVersions",
+	},
+	{
+		since: "2.3.0", group: "source.toolkit.fluxcd.io", kind: "HelmRepository", plural: "helmrepositories",
+		storedVersion: "v1beta1",
+		step:          "rewrite the %s objects stored as v1beta1 and remove v1beta1 from the CRD status.storedVersions",
+	},
+	{
+		since: "2.3.0", group: "source.toolkit.fluxcd.io", kind: "Bucket", plural: "buckets",
+		storedVersion: "v1beta1",
+		step:          "rewrite the %s objects stored as v1beta1 and remove v1beta1 from the CRD status.storedVersions",
+	},
+	{
+		since: "2.3.0", group: "kustomize.toolkit.fluxcd.io", kind: "Kustomization", plural: "kustomizations",
+		storedVersion: "v1beta1",
+		step:          "rewrite the %s objects stored as v1beta1 and remove v1beta1 from the CRD status.storedVersions",
+	},
+	{
+		since: "2.3.0", group: "notification.toolkit.fluxcd.io", kind: "Alert", plural: "alerts",
+		storedVersion: "v1beta1",
+		step:          "rewrite the %s objects stored as v1beta1 and remove v1beta1 from the CRD status.storedVersions",
+	},
+	{
+		since: "2.3.0", group: "notification.toolkit.fluxcd.io", kind: "Provider", plural: "providers",
+		storedVersion: "v1beta1",
+		step:          "rewrite the %s objects stored as v1beta1 and remove v1beta1 from the CRD status.storedVersions",
+	},
+	{
+		since: "2.3.0", group: "notification.toolkit.fluxcd.io", kind: "Receiver", plural: "receivers",
+		storedVersion: "v1beta1",
+		step:          "rewrite the %s objects stored as v1beta1 and remove v1beta1 from the CRD status.storedVersions",
+	},
+}
+
+// upgradePlanCheck analyzes the cluster for the changes introduced between
+// the installed Flux version and the target one, and prints the migration
+// steps required before upgrading.
+func upgradePlanCheck(ctx context.Context, target string) bool {
+	targetVersion, err := semver.ParseTolerant(target)
+	if err != nil {
+		logger.Failuref("invalid target version '%s': %s", target, err.Error())
+		return false
+	}
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
+		return false
+	}
+
+	installed, err := installedFluxVersion(ctx, kubeClient)
+	switch {
+	case err != nil:
+		logger.Failuref("installed version can't be determined: %s", err.Error())
+		return false
+	case installed == nil:
+		logger.Waitingf("installed version is unknown, checking all the changes up to v%s", targetVersion)
+	case !targetVersion.GT(*installed):
+		logger.Successf("installed version v%s is not older than v%s", installed, targetVersion)
+		return true
+	default:
+		logger.Actionf("checking the changes from v%s to v%s", installed, targetVersion)
+	}
+
+	ok := true
+	var steps []string
+	for _, rule := range upgradeRules {
+		since := semver.MustParse(rule.since)
+		if since.GT(targetVersion) || (installed != nil && !since.GT(*installed)) {
+			continue
+		}
+		matches, err := rule.matches(ctx, kubeClient)
+		if err != nil {
+			logger.Failuref("%s: %s", rule.kind, err.Error())
+			ok = false
+			continue
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		steps = append(steps, fmt.Sprintf("[v%s] %s", rule.since, fmt.Sprintf(rule.step, strings.Join(matches, ", "))))
+	}
+	if !ok {
+		return false
+	}
+
+	if len(steps) == 0 {
+		logger.Successf("no migration steps required to upgrade to v%s", targetVersion)
+		return true
+	}
+	logger.Generatef("upgrade plan to v%s", targetVersion)
+	for i, step := range steps {
+		fmt.Printf("%d. %s\n", i+1, step)
+	}
+	fmt.Printf("%d. flux install --version=v%s\n", len(steps)+1, targetVersion)
+	return true
+}
+
+// installedFluxVersion returns the version from the labels of the Flux
+// namespace, or nil if the namespace isn't labeled.
+func installedFluxVersion(ctx context.Context, kubeClient client.Client) (*semver.Version, error) {
+	var namespace corev1.Namespace
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: rootArgs.namespace}, &namespace); err != nil {
+		return nil, err
+	}
+	label, ok := namespace.Labels["app.kubernetes.io/version"]
+	if !ok {
+		return nil, nil
+	}
+	v, err := semver.ParseTolerant(label)
+	if err != nil {
+		return nil, nil
+	}
+	return &v, nil
+}
+
+// matches returns the references of the cluster objects affected by the rule.
+func (r upgradeRule) matches(ctx context.Context, kubeClient client.Client) ([]string, error) {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"})
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s.%s", r.plural, r.group)}, crd); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if r.storedVersion != "" {
+		stored, _, _ := unstructured.NestedStringSlice(crd.Object, "status", "storedVersions")
+		for _, v := range stored {
+			if v == r.storedVersion {
+				return []string{r.kind}, nil
+			}
+		}
+		return nil, nil
+	}
+
+	// list the objects in the storage version, so that fields removed
+	// from newer versions are still visible
+	version := ""
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, v := range versions {
+		if m, ok := v.(map[string]interface{}); ok && m["storage"] == true {
+			version, _ = m["name"].(string)
+		}
+	}
+	if version == "" {
+		return nil, fmt.Errorf("storage version of CRD %s can't be determined", crd.GetName())
+	}
+
+	var list unstructured.UnstructuredList
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: r.group, Version: version, Kind: r.kind + "List"})
+	if err := kubeClient.List(ctx, &list); err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, item := range list.Items {
+		_, found, _ := unstructured.NestedFieldNoCopy(item.Object, r.field...)
+		if found != r.missing {
+			matches = append(matches, fmt.Sprintf("%s/%s/%s", r.kind, item.GetNamespace(), item.GetName()))
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+// colorEnabled tells if the output written to the given file should be
+// colorized. The NO_COLOR and FORCE_COLOR environment variables are only
+// taken into account in auto mode, so an explicit --color flag wins.
+func colorEnabled(mode flags.Color, f *os.File) bool {
+	switch mode {
+	case flags.ColorNever:
+		return false
+	case flags.ColorAlways:
+		enableVirtualTerminal(f)
+		return true
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" {
+		enableVirtualTerminal(f)
+		return true
+	}
+	if os.Getenv("TERM") == "dumb" || !isTerminal(f) {
+		return false
+	}
+	return enableVirtualTerminal(f)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func configureColor() {
+	logger.colorize = colorEnabled(rootArgs.color, os.Stderr)
+	utils.SetColorOutput(colorEnabled(rootArgs.color, os.Stdout))
+}
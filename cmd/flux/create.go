@@ -100,11 +100,11 @@ type upsertWaitable interface {
 // upsertAndWait encodes the pattern of creating or updating a
 // resource, then waiting for it to reconcile. See the note on
 // `upsert` for how to work with the `mutate` argument.
-func (names apiType) upsertAndWait(object upsertWaitable, mutate func() error) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+func (names apiType) upsertAndWait(ctx context.Context, object upsertWaitable, mutate func() error) error {
+	ctx, cancel := context.WithTimeout(ctx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext) // NB globals
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout) // NB globals
 	if err != nil {
 		return err
 	}
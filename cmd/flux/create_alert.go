@@ -31,6 +31,7 @@ import (
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
 	"github.com/fluxcd/pkg/apis/meta"
 
+	"github.com/fluxcd/flux2/internal/flags"
 	"github.com/fluxcd/flux2/internal/utils"
 )
 
@@ -50,7 +51,7 @@ var createAlertCmd = &cobra.Command{
 
 type alertFlags struct {
 	providerRef   string
-	eventSeverity string
+	eventSeverity flags.EventSeverity
 	eventSources  []string
 }
 
@@ -58,7 +59,7 @@ var alertArgs alertFlags
 
 func init() {
 	createAlertCmd.Flags().StringVar(&alertArgs.providerRef, "provider-ref", "", "reference to provider")
-	createAlertCmd.Flags().StringVar(&alertArgs.eventSeverity, "event-severity", "", "severity of events to send alerts for")
+	createAlertCmd.Flags().Var(&alertArgs.eventSeverity, "event-severity", alertArgs.eventSeverity.Description())
 	createAlertCmd.Flags().StringArrayVar(&alertArgs.eventSources, "event-source", []string{}, "sources that should generate alerts (<kind>/<name>)")
 	createCmd.AddCommand(createAlertCmd)
 }
@@ -109,7 +110,7 @@ func createAlertCmdRun(cmd *cobra.Command, args []string) error {
 			ProviderRef: meta.LocalObjectReference{
 				Name: alertArgs.providerRef,
 			},
-			EventSeverity: alertArgs.eventSeverity,
+			EventSeverity: alertArgs.eventSeverity.String(),
 			EventSources:  eventSources,
 			Suspend:       false,
 		},
@@ -119,10 +120,10 @@ func createAlertCmdRun(cmd *cobra.Command, args []string) error {
 		return exportAlert(alert)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -110,7 +110,7 @@ func createImagePolicyRun(cmd *cobra.Command, args []string) error {
 
 	var existing imagev1.ImagePolicy
 	copyName(&existing, &policy)
-	err = imagePolicyType.upsertAndWait(imagePolicyAdapter{&existing}, func() error {
+	err = imagePolicyType.upsertAndWait(cmd.Context(), imagePolicyAdapter{&existing}, func() error {
 		existing.Spec = policy.Spec
 		existing.SetLabels(policy.Labels)
 		return nil
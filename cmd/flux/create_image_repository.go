@@ -102,7 +102,7 @@ func createImageRepositoryRun(cmd *cobra.Command, args []string) error {
 	// a temp value for use with the rest
 	var existing imagev1.ImageRepository
 	copyName(&existing, &repo)
-	err = imageRepositoryType.upsertAndWait(imageRepositoryAdapter{&existing}, func() error {
+	err = imageRepositoryType.upsertAndWait(cmd.Context(), imageRepositoryAdapter{&existing}, func() error {
 		existing.Spec = repo.Spec
 		existing.Labels = repo.Labels
 		return nil
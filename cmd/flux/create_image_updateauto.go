@@ -106,7 +106,7 @@ func createImageUpdateRun(cmd *cobra.Command, args []string) error {
 
 	var existing autov1.ImageUpdateAutomation
 	copyName(&existing, &update)
-	err = imageUpdateAutomationType.upsertAndWait(imageUpdateAutomationAdapter{&existing}, func() error {
+	err = imageUpdateAutomationType.upsertAndWait(cmd.Context(), imageUpdateAutomationAdapter{&existing}, func() error {
 		existing.Spec = update.Spec
 		existing.Labels = update.Labels
 		return nil
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -63,6 +64,13 @@ var createKsCmd = &cobra.Command{
     --interval=5m \
     --validation=client
 
+  # Create a Kustomization resource that applies the manifests in a new namespace
+  flux create kustomization podinfo \
+    --source=podinfo \
+    --path="./kustomize" \
+    --target-namespace=podinfo \
+    --create-target-namespace
+
   # Create a Kustomization resource that references a Bucket
   flux create kustomization secrets \
     --source=Bucket/secrets \
@@ -84,6 +92,7 @@ type kustomizationFlags struct {
 	decryptionProvider flags.DecryptionProvider
 	decryptionSecret   string
 	targetNamespace    string
+	createTargetNs     bool
 }
 
 var kustomizationArgs = NewKustomizationFlags()
@@ -100,6 +109,8 @@ func init() {
 	createKsCmd.Flags().Var(&kustomizationArgs.decryptionProvider, "decryption-provider", kustomizationArgs.decryptionProvider.Description())
 	createKsCmd.Flags().StringVar(&kustomizationArgs.decryptionSecret, "decryption-secret", "", "set the Kubernetes secret name that contains the OpenPGP private keys used for sops decryption")
 	createKsCmd.Flags().StringVar(&kustomizationArgs.targetNamespace, "target-namespace", "", "overrides the namespace of all Kustomization objects reconciled by this Kustomization")
+	createKsCmd.Flags().BoolVar(&kustomizationArgs.createTargetNs, "create-target-namespace", false,
+		"create the target namespace if it does not exist, with --export the Namespace manifest is included in the output")
 	createCmd.AddCommand(createKsCmd)
 }
 
@@ -121,6 +132,9 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 	if !strings.HasPrefix(kustomizationArgs.path.String(), "./") {
 		return fmt.Errorf("path must begin with ./")
 	}
+	if kustomizationArgs.createTargetNs && kustomizationArgs.targetNamespace == "" {
+		return fmt.Errorf("--create-target-namespace requires --target-namespace")
+	}
 
 	if !createArgs.export {
 		logger.Generatef("generating Kustomization")
@@ -209,18 +223,36 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	targetNamespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   kustomizationArgs.targetNamespace,
+			Labels: kslabels,
+		},
+	}
+
 	if createArgs.export {
+		if kustomizationArgs.createTargetNs {
+			if err := exportNamespace(targetNamespace); err != nil {
+				return err
+			}
+		}
 		return exportKs(kustomization)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
 
+	if kustomizationArgs.createTargetNs {
+		if err := createNamespaceIfMissing(ctx, kubeClient, targetNamespace); err != nil {
+			return err
+		}
+	}
+
 	logger.Actionf("applying Kustomization")
 	namespacedName, err := upsertKustomization(ctx, kubeClient, &kustomization)
 	if err != nil {
@@ -293,3 +325,17 @@ func isKustomizationReady(ctx context.Context, kubeClient client.Client,
 		return false, nil
 	}
 }
+
+// createNamespaceIfMissing creates the target namespace of a Kustomization,
+// an existing namespace is left untouched along with its labels.
+func createNamespaceIfMissing(ctx context.Context, kubeClient client.Client, namespace corev1.Namespace) error {
+	if err := kubeClient.Create(ctx, &namespace); err != nil {
+		if errors.IsAlreadyExists(err) {
+			logger.Successf("namespace %s already exists", namespace.Name)
+			return nil
+		}
+		return err
+	}
+	logger.Successf("namespace %s created", namespace.Name)
+	return nil
+}
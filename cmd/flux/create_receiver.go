@@ -18,14 +18,21 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/version"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
@@ -37,7 +44,8 @@ import (
 var createReceiverCmd = &cobra.Command{
 	Use:   "receiver [name]",
 	Short: "Create or update a Receiver resource",
-	Long:  "The create receiver command generates a Receiver resource.",
+	Long: `The create receiver command generates a Receiver resource.
+When no secret is referenced, a random token is generated and stored in a secret named '<name>-token'.`,
 	Example: `  # Create a Receiver
   flux create receiver github-receiver \
 	--type github \
@@ -46,6 +54,12 @@ var createReceiverCmd = &cobra.Command{
 	--secret-ref webhook-token \
 	--resource GitRepository/webapp \
 	--resource HelmRepository/webapp
+
+  # Create a Receiver with a generated token for a list of resources
+  flux create receiver github-receiver \
+	--type github \
+	--event push \
+	--resource GitRepository/webapp,GitRepository/backend
 `,
 	RunE: createReceiverCmdRun,
 }
@@ -61,9 +75,11 @@ var receiverArgs receiverFlags
 
 func init() {
 	createReceiverCmd.Flags().StringVar(&receiverArgs.receiverType, "type", "", "")
-	createReceiverCmd.Flags().StringVar(&receiverArgs.secretRef, "secret-ref", "", "")
+	createReceiverCmd.Flags().StringVar(&receiverArgs.secretRef, "secret-ref", "",
+		"the name of the secret containing the webhook token, when not specified a token is generated")
 	createReceiverCmd.Flags().StringArrayVar(&receiverArgs.events, "event", []string{}, "")
-	createReceiverCmd.Flags().StringArrayVar(&receiverArgs.resources, "resource", []string{}, "")
+	createReceiverCmd.Flags().StringSliceVar(&receiverArgs.resources, "resource", []string{},
+		"the resources to reconcile in the format '<kind>/<name>', accepts a comma separated list")
 	createCmd.AddCommand(createReceiverCmd)
 }
 
@@ -77,15 +93,11 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Receiver type is required")
 	}
 
-	if receiverArgs.secretRef == "" {
-		return fmt.Errorf("secret ref is required")
-	}
-
 	resources := []notificationv1.CrossNamespaceObjectReference{}
 	for _, resource := range receiverArgs.resources {
 		kind, name := utils.ParseObjectKindName(resource)
 		if kind == "" {
-			return fmt.Errorf("invalid event source '%s', must be in format <kind>/<name>", resource)
+			return fmt.Errorf("invalid resource '%s', must be in format <kind>/<name>", resource)
 		}
 
 		resources = append(resources, notificationv1.CrossNamespaceObjectReference{
@@ -103,6 +115,11 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	secretName := receiverArgs.secretRef
+	if secretName == "" {
+		secretName = fmt.Sprintf("%s-token", name)
+	}
+
 	if !createArgs.export {
 		logger.Generatef("generating Receiver")
 	}
@@ -118,24 +135,46 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 			Events:    receiverArgs.events,
 			Resources: resources,
 			SecretRef: meta.LocalObjectReference{
-				Name: receiverArgs.secretRef,
+				Name: secretName,
 			},
 			Suspend: false,
 		},
 	}
 
 	if createArgs.export {
+		if receiverArgs.secretRef == "" {
+			secret, err := generateReceiverSecret(secretName, sourceLabels)
+			if err != nil {
+				return err
+			}
+			if err := exportSecret(secret); err != nil {
+				return err
+			}
+		}
 		return exportReceiver(receiver)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
 
+	logger.Actionf("checking resources")
+	for _, resource := range resources {
+		if err := receiverResourceExists(ctx, kubeClient, rootArgs.namespace, resource); err != nil {
+			return err
+		}
+	}
+
+	if receiverArgs.secretRef == "" {
+		if err := ensureReceiverSecret(ctx, kubeClient, secretName, sourceLabels); err != nil {
+			return err
+		}
+	}
+
 	logger.Actionf("applying Receiver")
 	namespacedName, err := upsertReceiver(ctx, kubeClient, &receiver)
 	if err != nil {
@@ -150,9 +189,105 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Successf("Receiver %s is ready", name)
 
 	logger.Successf("generated webhook URL %s", receiver.Status.URL)
+	if receiverArgs.secretRef == "" {
+		logger.Successf("retrieve the webhook token with: kubectl -n %s get secret %s -o jsonpath='{.data.token}' | base64 -d",
+			rootArgs.namespace, secretName)
+	}
 	return nil
 }
 
+// generateReceiverSecret returns a secret holding a random webhook token.
+func generateReceiverSecret(name string, labels map[string]string) (corev1.Secret, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return corev1.Secret{}, fmt.Errorf("failed to generate webhook token: %w", err)
+	}
+
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: rootArgs.namespace,
+			Labels:    labels,
+		},
+		StringData: map[string]string{
+			"token": hex.EncodeToString(b),
+		},
+	}, nil
+}
+
+// ensureReceiverSecret generates the webhook token secret, an existing
+// token is kept so that the webhooks configured upstream remain valid.
+func ensureReceiverSecret(ctx context.Context, kubeClient client.Client, name string, labels map[string]string) error {
+	var existing corev1.Secret
+	err := kubeClient.Get(ctx, types.NamespacedName{Namespace: rootArgs.namespace, Name: name}, &existing)
+	if err == nil && len(existing.Data["token"]) > 0 {
+		logger.Successf("using existing token from secret %s", name)
+		return nil
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	secret, err := generateReceiverSecret(name, labels)
+	if err != nil {
+		return err
+	}
+	if err := upsertSecret(ctx, kubeClient, secret); err != nil {
+		return err
+	}
+	logger.Successf("generated token secret %s", name)
+	return nil
+}
+
+// receiverResourceExists checks that the resource referenced by the
+// Receiver is present in the cluster.
+func receiverResourceExists(ctx context.Context, kubeClient client.Client,
+	namespace string, resource notificationv1.CrossNamespaceObjectReference) error {
+	gvk, ok := fluxKindToGVK(kubeClient, resource.Kind)
+	if !ok {
+		return fmt.Errorf("unsupported resource kind '%s'", resource.Kind)
+	}
+
+	obj := &metav1.PartialObjectMetadata{}
+	obj.SetGroupVersionKind(gvk)
+	err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: resource.Name}, obj)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("%s/%s not found in %s namespace", resource.Kind, resource.Name, namespace)
+		}
+		return err
+	}
+	return nil
+}
+
+// fluxKindToGVK looks up a toolkit kind in the client scheme. When the
+// kind is registered under several versions, the version preferred by
+// the API server is used, or the highest one if the server can't tell.
+func fluxKindToGVK(kubeClient client.Client, kind string) (schema.GroupVersionKind, bool) {
+	var candidates []schema.GroupVersionKind
+	for gvk := range kubeClient.Scheme().AllKnownTypes() {
+		if gvk.Kind == kind && strings.HasSuffix(gvk.Group, ".toolkit.fluxcd.io") {
+			candidates = append(candidates, gvk)
+		}
+	}
+	if len(candidates) == 0 {
+		return schema.GroupVersionKind{}, false
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Group != candidates[j].Group {
+			return candidates[i].Group < candidates[j].Group
+		}
+		return version.CompareKubeAwareVersionStrings(candidates[i].Version, candidates[j].Version) > 0
+	})
+
+	if mapper := kubeClient.RESTMapper(); mapper != nil {
+		if mapping, err := mapper.RESTMapping(candidates[0].GroupKind()); err == nil {
+			return mapping.GroupVersionKind, true
+		}
+	}
+	return candidates[0], true
+}
+
 func upsertReceiver(ctx context.Context, kubeClient client.Client,
 	receiver *notificationv1.Receiver) (types.NamespacedName, error) {
 	namespacedName := types.NamespacedName{
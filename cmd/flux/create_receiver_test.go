@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+)
+
+func TestFluxKindToGVK(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, version := range []string{"v1alpha1", "v1beta1", "v1beta2", "v1alpha2"} {
+		gvk := schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: version, Kind: kustomizev1.KustomizationKind}
+		scheme.AddKnownTypeWithName(gvk, &kustomizev1.Kustomization{})
+	}
+	kubeClient := fake.NewFakeClientWithScheme(scheme)
+
+	// the scheme types are iterated in random order
+	for i := 0; i < 20; i++ {
+		gvk, ok := fluxKindToGVK(kubeClient, kustomizev1.KustomizationKind)
+		if !ok {
+			t.Fatalf("kind %s not found", kustomizev1.KustomizationKind)
+		}
+		if gvk.Version != "v1beta2" {
+			t.Fatalf("version = %s, expect v1beta2", gvk.Version)
+		}
+	}
+
+	if _, ok := fluxKindToGVK(kubeClient, "GitRepository"); ok {
+		t.Errorf("expected GitRepository not to be found")
+	}
+}
@@ -42,12 +42,13 @@ import (
 )
 
 type SourceGitFlags struct {
-	GitURL      string
-	GitBranch   string
-	GitTag      string
-	GitSemver   string
-	GitUsername string
-	GitPassword string
+	GitURL         string
+	GitBranch      string
+	GitTag         string
+	GitSemver      string
+	GitUsername    string
+	GitPassword    string
+	GitBearerToken string
 
 	GitKeyAlgorithm   flags.PublicKeyAlgorithm
 	GitRSABits        flags.RSAKeyBits
@@ -96,6 +97,20 @@ For private Git repositories, the basic authentication credentials are stored in
     --url=https://github.com/stefanprodan/podinfo \
     --username=username \
     --password=password
+
+  # Create a source from a Git repository using a token, the credentials
+  # are stored in the secret referenced by the GitRepository
+  flux create source git podinfo \
+    --url=https://github.com/stefanprodan/podinfo \
+    --bearer-token=${GITHUB_TOKEN} \
+    --secret-ref=podinfo-auth
+
+  # Export the credentials secret along with the GitRepository
+  flux create source git podinfo \
+    --url=https://github.com/stefanprodan/podinfo \
+    --username=username \
+    --password=password \
+    --export > podinfo.yaml
 `,
 	RunE: createSourceGitCmdRun,
 }
@@ -109,10 +124,13 @@ func init() {
 	createSourceGitCmd.Flags().StringVar(&sourceArgs.GitSemver, "tag-semver", "", "git tag semver range")
 	createSourceGitCmd.Flags().StringVarP(&sourceArgs.GitUsername, "username", "u", "", "basic authentication username")
 	createSourceGitCmd.Flags().StringVarP(&sourceArgs.GitPassword, "password", "p", "", "basic authentication password")
+	createSourceGitCmd.Flags().StringVar(&sourceArgs.GitBearerToken, "bearer-token", "",
+		"access token of the Git host, stored as the basic authentication password of the 'git' user")
 	createSourceGitCmd.Flags().Var(&sourceArgs.GitKeyAlgorithm, "ssh-key-algorithm", sourceArgs.GitKeyAlgorithm.Description())
 	createSourceGitCmd.Flags().Var(&sourceArgs.GitRSABits, "ssh-rsa-bits", sourceArgs.GitRSABits.Description())
 	createSourceGitCmd.Flags().Var(&sourceArgs.GitECDSACurve, "ssh-ecdsa-curve", sourceArgs.GitECDSACurve.Description())
-	createSourceGitCmd.Flags().StringVarP(&sourceArgs.GitSecretRef, "secret-ref", "", "", "the name of an existing secret containing SSH or basic credentials")
+	createSourceGitCmd.Flags().StringVarP(&sourceArgs.GitSecretRef, "secret-ref", "", "",
+		"the name of an existing secret containing SSH or basic credentials, created or updated when credentials are given")
 	createSourceGitCmd.Flags().Var(&sourceArgs.GitImplementation, "git-implementation", sourceArgs.GitImplementation.Description())
 
 	createSourceCmd.AddCommand(createSourceGitCmd)
@@ -147,6 +165,10 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("git URL parse failed: %w", err)
 	}
 
+	if err := validateGitCredentials(u); err != nil {
+		return err
+	}
+
 	sourceLabels, err := parseLabels()
 	if err != nil {
 		return err
@@ -180,7 +202,14 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	if createArgs.export {
-		if sourceArgs.GitSecretRef != "" {
+		if secret, ok := gitCredentialsSecret(name, sourceLabels); ok {
+			if err := exportSecret(secret); err != nil {
+				return err
+			}
+			gitRepository.Spec.SecretRef = &meta.LocalObjectReference{
+				Name: secret.Name,
+			}
+		} else if sourceArgs.GitSecretRef != "" {
 			gitRepository.Spec.SecretRef = &meta.LocalObjectReference{
 				Name: sourceArgs.GitSecretRef,
 			}
@@ -188,17 +217,23 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 		return exportGit(gitRepository)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
 
 	withAuth := false
 	// TODO(hidde): move all auth prep to separate func?
-	if sourceArgs.GitSecretRef != "" {
+	if secret, ok := gitCredentialsSecret(name, sourceLabels); ok {
+		logger.Actionf("applying secret with basic auth credentials")
+		if err := upsertSecret(ctx, kubeClient, secret); err != nil {
+			return err
+		}
+		withAuth = true
+	} else if sourceArgs.GitSecretRef != "" {
 		withAuth = true
 	} else if u.Scheme == "ssh" {
 		logger.Generatef("generating deploy key pair")
@@ -240,23 +275,6 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		withAuth = true
-	} else if sourceArgs.GitUsername != "" && sourceArgs.GitPassword != "" {
-		logger.Actionf("applying secret with basic auth credentials")
-		secret := corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      name,
-				Namespace: rootArgs.namespace,
-				Labels:    sourceLabels,
-			},
-			StringData: map[string]string{
-				"username": sourceArgs.GitUsername,
-				"password": sourceArgs.GitPassword,
-			},
-		}
-		if err := upsertSecret(ctx, kubeClient, secret); err != nil {
-			return err
-		}
-		withAuth = true
 	}
 
 	if withAuth {
@@ -295,6 +313,51 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// validateGitCredentials checks that the HTTPS credentials flags are
+// complete and used with an HTTP/S URL.
+func validateGitCredentials(u *url.URL) error {
+	basicAuth := sourceArgs.GitUsername != "" || sourceArgs.GitPassword != ""
+	if basicAuth && sourceArgs.GitBearerToken != "" {
+		return fmt.Errorf("--bearer-token can't be combined with --username and --password")
+	}
+	if basicAuth && (sourceArgs.GitUsername == "" || sourceArgs.GitPassword == "") {
+		return fmt.Errorf("both --username and --password are required for basic authentication")
+	}
+	if (basicAuth || sourceArgs.GitBearerToken != "") && u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("basic authentication and bearer tokens require an HTTP/S URL, got '%s'", u.Scheme)
+	}
+	return nil
+}
+
+// gitCredentialsSecret returns the secret holding the credentials given
+// with --username and --password or --bearer-token, with the keys
+// expected by source-controller. The secret is named after --secret-ref
+// when set, or else after the GitRepository.
+func gitCredentialsSecret(name string, labels map[string]string) (corev1.Secret, bool) {
+	username, password := sourceArgs.GitUsername, sourceArgs.GitPassword
+	if sourceArgs.GitBearerToken != "" {
+		username, password = "git", sourceArgs.GitBearerToken
+	}
+	if username == "" || password == "" {
+		return corev1.Secret{}, false
+	}
+
+	if sourceArgs.GitSecretRef != "" {
+		name = sourceArgs.GitSecretRef
+	}
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: rootArgs.namespace,
+			Labels:    labels,
+		},
+		StringData: map[string]string{
+			"username": username,
+			"password": password,
+		},
+	}, true
+}
+
 func upsertGitRepository(ctx context.Context, kubeClient client.Client,
 	gitRepository *sourcev1.GitRepository) (types.NamespacedName, error) {
 	namespacedName := types.NamespacedName{
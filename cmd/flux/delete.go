@@ -22,7 +22,12 @@ import (
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 
 	"github.com/fluxcd/flux2/internal/utils"
 )
@@ -31,10 +36,24 @@ var deleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete sources and resources",
 	Long:  "The delete sub-commands delete sources and resources.",
+	Example: `  # Delete all the kustomizations with a label, after typing the namespace name to confirm
+  flux delete kustomization --selector=team=dev
+
+  # List the sources that would be deleted
+  flux delete source git --all --dry-run
+
+  # Delete all the Helm releases in a namespace without confirmation
+  flux delete helmrelease --all --namespace=apps --yes
+`,
+	PersistentPreRunE: validateDeleteFlags,
 }
 
 type deleteFlags struct {
-	silent bool
+	silent   bool
+	selector string
+	all      bool
+	yes      bool
+	dryRun   bool
 }
 
 var deleteArgs deleteFlags
@@ -42,25 +61,127 @@ var deleteArgs deleteFlags
 func init() {
 	deleteCmd.PersistentFlags().BoolVarP(&deleteArgs.silent, "silent", "s", false,
 		"delete resource without asking for confirmation")
+	deleteCmd.PersistentFlags().StringVarP(&deleteArgs.selector, "selector", "l", "",
+		"delete the resources matching the label selector in the namespace, e.g. 'team=dev'")
+	deleteCmd.PersistentFlags().BoolVar(&deleteArgs.all, "all", false,
+		"delete all the resources of this kind in the namespace")
+	deleteCmd.PersistentFlags().BoolVar(&deleteArgs.yes, "yes", false,
+		"used with --all or --selector, delete the resources without typing the namespace name to confirm")
+	deleteCmd.PersistentFlags().BoolVar(&deleteArgs.dryRun, "dry-run", false,
+		"used with --all or --selector, only list the resources that would be deleted")
 
 	rootCmd.AddCommand(deleteCmd)
 }
 
+func (f deleteFlags) isBulk() bool {
+	return f.all || f.selector != ""
+}
+
+func validateDeleteFlags(cmd *cobra.Command, args []string) error {
+	if deleteArgs.all && deleteArgs.selector != "" {
+		return fmt.Errorf("--all and --selector are mutually exclusive")
+	}
+	if deleteArgs.isBulk() && len(args) > 0 {
+		return fmt.Errorf("a name can't be specified with --all or --selector")
+	}
+	if !deleteArgs.isBulk() && (deleteArgs.dryRun || deleteArgs.yes) {
+		return fmt.Errorf("--dry-run and --yes can only be used with --all or --selector")
+	}
+	return nil
+}
+
+// deleteBulk deletes the objects of the kind of obj matched by --all or
+// --selector in the namespace. Unless --yes or --silent is set, the user
+// has to type the namespace name to confirm the deletion.
+func deleteBulk(cmd *cobra.Command, obj client.Object, humanKind string) error {
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, kubeClient.Scheme())
+	if err != nil {
+		return err
+	}
+
+	listOpts := []client.ListOption{client.InNamespace(rootArgs.namespace)}
+	if deleteArgs.selector != "" {
+		selector, err := labels.Parse(deleteArgs.selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector '%s': %w", deleteArgs.selector, err)
+		}
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: selector})
+	}
+
+	var list metav1.PartialObjectMetadataList
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := kubeClient.List(ctx, &list, listOpts...); err != nil {
+		return err
+	}
+
+	if len(list.Items) == 0 {
+		logger.Failuref("no %s found in %s namespace", humanKind, rootArgs.namespace)
+		return nil
+	}
+
+	for _, item := range list.Items {
+		logger.Actionf("%s %s/%s", gvk.Kind, item.Namespace, item.Name)
+	}
+
+	if deleteArgs.dryRun {
+		logger.Successf("%d %s would be deleted (dry run)", len(list.Items), humanKind)
+		return nil
+	}
+
+	if !deleteArgs.yes && !deleteArgs.silent {
+		prompt := promptui.Prompt{
+			Label: fmt.Sprintf("Type the namespace name '%s' to delete %d %s", rootArgs.namespace, len(list.Items), humanKind),
+			Validate: func(input string) error {
+				if input != rootArgs.namespace {
+					return fmt.Errorf("input doesn't match the namespace name")
+				}
+				return nil
+			},
+		}
+		if _, err := prompt.Run(); err != nil {
+			return fmt.Errorf("aborting")
+		}
+	}
+
+	for i := range list.Items {
+		item := list.Items[i]
+		item.SetGroupVersionKind(gvk)
+		logger.Actionf("deleting %s %s in %s namespace", humanKind, item.Name, rootArgs.namespace)
+		if err := kubeClient.Delete(ctx, &item); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	logger.Successf("%d %s deleted", len(list.Items), humanKind)
+
+	return nil
+}
+
 type deleteCommand struct {
 	apiType
 	object adapter // for getting the value, and later deleting it
 }
 
 func (del deleteCommand) run(cmd *cobra.Command, args []string) error {
+	if deleteArgs.isBulk() {
+		return deleteBulk(cmd, del.object.asClientObject(), del.humanKind)
+	}
 	if len(args) < 1 {
 		return fmt.Errorf("%s name is required", del.humanKind)
 	}
 	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
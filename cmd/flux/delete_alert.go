@@ -43,15 +43,19 @@ func init() {
 }
 
 func deleteAlertCmdRun(cmd *cobra.Command, args []string) error {
+	if deleteArgs.isBulk() {
+		return deleteBulk(cmd, &notificationv1.Alert{}, "alerts")
+	}
+
 	if len(args) < 1 {
 		return fmt.Errorf("alert name is required")
 	}
 	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -44,15 +44,19 @@ func init() {
 }
 
 func deleteHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
+	if deleteArgs.isBulk() {
+		return deleteBulk(cmd, &helmv2.HelmRelease{}, "Helm releases")
+	}
+
 	if len(args) < 1 {
 		return fmt.Errorf("release name is required")
 	}
 	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -32,7 +32,7 @@ var deleteKsCmd = &cobra.Command{
 	Aliases: []string{"ks"},
 	Short:   "Delete a Kustomization resource",
 	Long:    "The delete kustomization command deletes the given Kustomization from the cluster.",
-	Example: `  # Delete a kustomization and the Kubernetes resources created by it
+	Example: `  # Delete a kustomization and the Kubernetes resources created by it when prune is enabled
   flux delete kustomization podinfo
 `,
 	RunE: deleteKsCmdRun,
@@ -43,15 +43,19 @@ func init() {
 }
 
 func deleteKsCmdRun(cmd *cobra.Command, args []string) error {
+	if deleteArgs.isBulk() {
+		return deleteBulk(cmd, &kustomizev1.Kustomization{}, "kustomizations")
+	}
+
 	if len(args) < 1 {
 		return fmt.Errorf("kustomization name is required")
 	}
 	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -68,7 +72,7 @@ func deleteKsCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	if !deleteArgs.silent {
-		if !kustomization.Spec.Suspend {
+		if kustomization.Spec.Prune && !kustomization.Spec.Suspend {
 			logger.Waitingf("This action will remove the Kubernetes objects previously applied by the %s kustomization!", name)
 		}
 		prompt := promptui.Prompt{
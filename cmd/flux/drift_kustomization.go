@@ -0,0 +1,453 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/portforward"
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var driftKsCmd = &cobra.Command{
+	Use:     "kustomization [name]",
+	Aliases: []string{"ks"},
+	Short:   "Detect drift of the objects applied by a Kustomization",
+	Long: `The drift kustomization command builds the source artifact of a Kustomization,
+performs a server-side dry-run apply of the resulting objects and compares the outcome with their live state.
+It lists the fields changed out-of-band along with the field managers that own them,
+and exits with an error when drift is detected.`,
+	Example: `  # Detect the changes made with kubectl to the objects of a Kustomization
+  flux drift kustomization podinfo
+`,
+	RunE: driftKsCmdRun,
+}
+
+func init() {
+	driftCmd.AddCommand(driftKsCmd)
+}
+
+const (
+	// kustomizeControllerManager is the field manager used by the
+	// dry-run apply, so that the fields owned by kustomize-controller
+	// are not reported as conflicts.
+	kustomizeControllerManager = "kustomize-controller"
+
+	// sourceControllerHTTPPort is the container port of the artifact
+	// server of source-controller.
+	sourceControllerHTTPPort = 9090
+)
+
+func driftKsCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("kustomization name is required")
+	}
+	name := args[0]
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+
+	var kustomization kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: rootArgs.namespace, Name: name}, &kustomization); err != nil {
+		return err
+	}
+
+	artifact, err := kustomizationArtifact(ctx, kubeClient, kustomization)
+	if err != nil {
+		return err
+	}
+	if kustomization.Status.LastAppliedRevision != "" && artifact.Revision != kustomization.Status.LastAppliedRevision {
+		logger.Failuref("the source revision %s differs from the last applied revision %s, drift is computed against the former",
+			artifact.Revision, kustomization.Status.LastAppliedRevision)
+	}
+
+	tmpDir, err := ioutil.TempDir("", name)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logger.Actionf("fetching artifact %s", artifact.Revision)
+	forwarder := portforward.NewManager(kubeClient, rootArgs.kubeconfig, rootArgs.kubecontext)
+	defer forwarder.Close()
+	if err := fetchArtifact(ctx, forwarder, artifact.URL, tmpDir); err != nil {
+		return err
+	}
+
+	logger.Generatef("building %s", kustomization.Spec.Path)
+	objects, err := buildKustomization(tmpDir, kustomization)
+	if err != nil {
+		return fmt.Errorf("build failed: %w", err)
+	}
+
+	logger.Actionf("comparing %d objects with their live state", len(objects))
+	var rows [][]string
+	drifted := 0
+	for _, obj := range objects {
+		ref := fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())
+		if obj.GetNamespace() != "" {
+			ref = fmt.Sprintf("%s/%s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		}
+		if obj.GetKind() == "Secret" && kustomization.Spec.Decryption != nil {
+			logger.Failuref("skipping %s, encrypted secrets can't be compared", ref)
+			continue
+		}
+
+		objRows, err := objectDrift(ctx, kubeClient, obj)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		if len(objRows) > 0 {
+			drifted++
+		}
+		for _, row := range objRows {
+			rows = append(rows, append([]string{ref}, row...))
+		}
+	}
+
+	if drifted == 0 {
+		logger.Successf("no drift detected")
+		return nil
+	}
+	utils.PrintTable(os.Stdout, []string{"Object", "Field", "Managers"}, rows)
+	return fmt.Errorf("drift detected in %d objects", drifted)
+}
+
+// kustomizationArtifact returns the artifact of the source of a Kustomization.
+func kustomizationArtifact(ctx context.Context, kubeClient client.Client, kustomization kustomizev1.Kustomization) (*sourcev1.Artifact, error) {
+	ref := kustomization.Spec.SourceRef
+	namespacedName := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	if namespacedName.Namespace == "" {
+		namespacedName.Namespace = kustomization.Namespace
+	}
+
+	var source sourcev1.Source
+	switch ref.Kind {
+	case sourcev1.GitRepositoryKind:
+		var repository sourcev1.GitRepository
+		if err := kubeClient.Get(ctx, namespacedName, &repository); err != nil {
+			return nil, err
+		}
+		source = &repository
+	case sourcev1.BucketKind:
+		var bucket sourcev1.Bucket
+		if err := kubeClient.Get(ctx, namespacedName, &bucket); err != nil {
+			return nil, err
+		}
+		source = &bucket
+	default:
+		return nil, fmt.Errorf("source kind '%s' is not supported", ref.Kind)
+	}
+	artifact := source.GetArtifact()
+	if artifact == nil {
+		return nil, fmt.Errorf("%s '%s' has no artifact", ref.Kind, namespacedName)
+	}
+	return artifact, nil
+}
+
+// fetchArtifact downloads an artifact from source-controller through a
+// port-forward and extracts it in dir. The artifact URL is of the form
+// http://source-controller.<namespace>.svc.<cluster-domain>./<path>.
+func fetchArtifact(ctx context.Context, forwarder *portforward.Manager, artifactURL, dir string) error {
+	u, err := url.Parse(artifactURL)
+	if err != nil {
+		return fmt.Errorf("invalid artifact URL '%s': %w", artifactURL, err)
+	}
+	labels := strings.Split(u.Hostname(), ".")
+	if len(labels) < 2 {
+		return fmt.Errorf("unexpected artifact host '%s'", u.Host)
+	}
+	address, err := forwarder.Address(ctx, portforward.Target{
+		Namespace:  labels[1],
+		Controller: labels[0],
+		Port:       sourceControllerHTTPPort,
+	})
+	if err != nil {
+		return err
+	}
+	u.Host = address
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("artifact download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("artifact download failed: %s", resp.Status)
+	}
+	return untar(resp.Body, dir)
+}
+
+// untar extracts a gzip compressed tarball in dir, rejecting the entries
+// that would be written outside of it.
+func untar(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, header.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path '%s' in artifact", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// buildKustomization builds the objects of a Kustomization from its
+// extracted artifact, setting the target namespace and images overrides
+// the same way kustomize-controller does.
+func buildKustomization(dir string, kustomization kustomizev1.Kustomization) ([]unstructured.Unstructured, error) {
+	path := filepath.Join(dir, kustomization.Spec.Path)
+	if !strings.HasPrefix(filepath.Clean(path)+string(os.PathSeparator), filepath.Clean(dir)+string(os.PathSeparator)) {
+		return nil, fmt.Errorf("path '%s' is outside of the artifact", kustomization.Spec.Path)
+	}
+	if err := utils.GenerateKustomizationYaml(path); err != nil {
+		return nil, err
+	}
+
+	kfile := filepath.Join(path, "kustomization.yaml")
+	data, err := ioutil.ReadFile(kfile)
+	if err != nil {
+		return nil, err
+	}
+	var kus kustypes.Kustomization
+	if err := yaml.Unmarshal(data, &kus); err != nil {
+		return nil, err
+	}
+	if kustomization.Spec.TargetNamespace != "" {
+		kus.Namespace = kustomization.Spec.TargetNamespace
+	}
+	for _, image := range kustomization.Spec.Images {
+		kus.Images = append(kus.Images, kustypes.Image{
+			Name:    image.Name,
+			NewName: image.NewName,
+			NewTag:  image.NewTag,
+		})
+	}
+	if data, err = yaml.Marshal(kus); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(kfile, data, 0644); err != nil {
+		return nil, err
+	}
+
+	k := krusty.MakeKustomizer(filesys.MakeFsOnDisk(), krusty.MakeDefaultOptions())
+	resources, err := k.Run(path)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := resources.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(string(manifests)), 2048)
+	for {
+		var obj unstructured.Unstructured
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if obj.Object != nil {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+// objectDrift returns the fields of an object which would be changed by
+// applying it, paired with their managers. The desired state is computed
+// with a server-side dry-run apply on top of the live object.
+func objectDrift(ctx context.Context, kubeClient client.Client, obj unstructured.Unstructured) ([][]string, error) {
+	mapping, err := kubeClient.RESTMapper().RESTMapping(obj.GroupVersionKind().GroupKind(), obj.GroupVersionKind().Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == apimeta.RESTScopeNameNamespace && obj.GetNamespace() == "" {
+		obj.SetNamespace("default")
+	}
+
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, live); err != nil {
+		if apierrors.IsNotFound(err) {
+			return [][]string{{"-", "deleted"}}, nil
+		}
+		return nil, err
+	}
+
+	desired := obj.DeepCopy()
+	if err := kubeClient.Patch(ctx, desired, client.Apply, client.DryRunAll,
+		client.FieldOwner(kustomizeControllerManager), client.ForceOwnership); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, path := range driftedFields(nil, desired.Object, live.Object) {
+		managers := fieldManagers(live, path)
+		if len(managers) == 0 {
+			managers = []string{"-"}
+		}
+		rows = append(rows, []string{strings.Join(path, "."), strings.Join(managers, ", ")})
+	}
+	return rows, nil
+}
+
+// ignoredDriftFields are set by the API server and never drift.
+var ignoredDriftFields = map[string]bool{
+	"status":                     true,
+	"metadata.managedFields":     true,
+	"metadata.resourceVersion":   true,
+	"metadata.generation":        true,
+	"metadata.uid":               true,
+	"metadata.creationTimestamp": true,
+	"metadata.selfLink":          true,
+}
+
+// driftedFields returns the paths of the fields whose desired and live
+// values differ, lists are compared as a whole.
+func driftedFields(prefix []string, desired, live interface{}) [][]string {
+	if ignoredDriftFields[strings.Join(prefix, ".")] {
+		return nil
+	}
+	desiredMap, ok1 := desired.(map[string]interface{})
+	liveMap, ok2 := live.(map[string]interface{})
+	if !ok1 || !ok2 {
+		if reflect.DeepEqual(desired, live) {
+			return nil
+		}
+		return [][]string{prefix}
+	}
+
+	keys := map[string]bool{}
+	for k := range desiredMap {
+		keys[k] = true
+	}
+	for k := range liveMap {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var paths [][]string
+	for _, k := range sorted {
+		path := append(append([]string{}, prefix...), k)
+		paths = append(paths, driftedFields(path, desiredMap[k], liveMap[k])...)
+	}
+	return paths
+}
+
+// fieldManagers returns the managers owning a field of the live object,
+// read from its managed fields, e.g. "kubectl-edit (Update)".
+func fieldManagers(live *unstructured.Unstructured, path []string) []string {
+	var managers []string
+	for _, entry := range live.GetManagedFields() {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		owned := true
+		for _, key := range path {
+			next, ok := fields["f:"+key].(map[string]interface{})
+			if !ok {
+				owned = false
+				break
+			}
+			fields = next
+		}
+		if owned {
+			managers = append(managers, fmt.Sprintf("%s (%s)", entry.Manager, entry.Operation))
+		}
+	}
+	return managers
+}
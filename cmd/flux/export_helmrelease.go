@@ -40,11 +40,21 @@ var exportHelmReleaseCmd = &cobra.Command{
 
   # Export a HelmRelease
   flux export hr my-app > app-release.yaml
+
+  # Export a HelmRelease along with its chart source
+  flux export hr my-app --with-source > app-release.yaml
 `,
 	RunE: exportHelmReleaseCmdRun,
 }
 
+type exportHelmReleaseFlags struct {
+	withSource bool
+}
+
+var exportHelmReleaseArgs exportHelmReleaseFlags
+
 func init() {
+	addExportWithSourceFlag(exportHelmReleaseCmd, &exportHelmReleaseArgs.withSource)
 	exportCmd.AddCommand(exportHelmReleaseCmd)
 }
 
@@ -53,13 +63,14 @@ func exportHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("name is required")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
+	sources := newSourceExporter(kubeClient)
 
 	if exportArgs.all {
 		var list helmv2.HelmReleaseList
@@ -74,7 +85,7 @@ func exportHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 		}
 
 		for _, helmRelease := range list.Items {
-			if err := exportHelmRelease(helmRelease); err != nil {
+			if err := exportHelmReleaseWithSource(ctx, sources, helmRelease); err != nil {
 				return err
 			}
 		}
@@ -89,11 +100,44 @@ func exportHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		return exportHelmRelease(helmRelease)
+		return exportHelmReleaseWithSource(ctx, sources, helmRelease)
 	}
 	return nil
 }
 
+// exportHelmReleaseWithSource exports a HelmRelease, preceded by its
+// chart source and the references to its secrets and config maps when
+// --with-source is set.
+func exportHelmReleaseWithSource(ctx context.Context, sources *sourceExporter, helmRelease helmv2.HelmRelease) error {
+	if !exportHelmReleaseArgs.withSource {
+		return exportHelmRelease(helmRelease)
+	}
+
+	ref := helmRelease.Spec.Chart.Spec.SourceRef
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = helmRelease.Namespace
+	}
+	if err := sources.export(ctx, ref.Kind, namespace, ref.Name); err != nil {
+		return err
+	}
+
+	var refs []objectReference
+	for _, values := range helmRelease.Spec.ValuesFrom {
+		refs = append(refs, objectReference{
+			kind:      values.Kind,
+			namespace: helmRelease.Namespace,
+			name:      values.Name,
+			usage:     "values",
+		})
+	}
+	if helmRelease.Spec.KubeConfig != nil {
+		refs = append(refs, secretReference(helmRelease.Namespace, &helmRelease.Spec.KubeConfig.SecretRef, "kubeconfig")...)
+	}
+	printReferences(helmv2.HelmReleaseKind, types.NamespacedName{Namespace: helmRelease.Namespace, Name: helmRelease.Name}, refs)
+	return exportHelmRelease(helmRelease)
+}
+
 func exportHelmRelease(helmRelease helmv2.HelmRelease) error {
 	gvk := helmv2.GroupVersion.WithKind(helmv2.HelmReleaseKind)
 	export := helmv2.HelmRelease{
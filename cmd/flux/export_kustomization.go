@@ -40,11 +40,21 @@ var exportKsCmd = &cobra.Command{
 
   # Export a Kustomization
   flux export kustomization my-app > kustomization.yaml
+
+  # Export a Kustomization along with its source
+  flux export kustomization my-app --with-source > app.yaml
 `,
 	RunE: exportKsCmdRun,
 }
 
+type exportKsFlags struct {
+	withSource bool
+}
+
+var exportKsArgs exportKsFlags
+
 func init() {
+	addExportWithSourceFlag(exportKsCmd, &exportKsArgs.withSource)
 	exportCmd.AddCommand(exportKsCmd)
 }
 
@@ -53,13 +63,14 @@ func exportKsCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("kustomization name is required")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
+	sources := newSourceExporter(kubeClient)
 
 	if exportArgs.all {
 		var list kustomizev1.KustomizationList
@@ -74,7 +85,7 @@ func exportKsCmdRun(cmd *cobra.Command, args []string) error {
 		}
 
 		for _, kustomization := range list.Items {
-			if err := exportKs(kustomization); err != nil {
+			if err := exportKsWithSource(ctx, sources, kustomization); err != nil {
 				return err
 			}
 		}
@@ -89,11 +100,38 @@ func exportKsCmdRun(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		return exportKs(kustomization)
+		return exportKsWithSource(ctx, sources, kustomization)
 	}
 	return nil
 }
 
+// exportKsWithSource exports a Kustomization, preceded by its source
+// and the references to its secrets when --with-source is set.
+func exportKsWithSource(ctx context.Context, sources *sourceExporter, kustomization kustomizev1.Kustomization) error {
+	if !exportKsArgs.withSource {
+		return exportKs(kustomization)
+	}
+
+	ref := kustomization.Spec.SourceRef
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = kustomization.Namespace
+	}
+	if err := sources.export(ctx, ref.Kind, namespace, ref.Name); err != nil {
+		return err
+	}
+
+	var refs []objectReference
+	if kustomization.Spec.Decryption != nil {
+		refs = append(refs, secretReference(kustomization.Namespace, kustomization.Spec.Decryption.SecretRef, "decryption")...)
+	}
+	if kustomization.Spec.KubeConfig != nil {
+		refs = append(refs, secretReference(kustomization.Namespace, &kustomization.Spec.KubeConfig.SecretRef, "kubeconfig")...)
+	}
+	printReferences(kustomizev1.KustomizationKind, types.NamespacedName{Namespace: kustomization.Namespace, Name: kustomization.Name}, refs)
+	return exportKs(kustomization)
+}
+
 func exportKs(kustomization kustomizev1.Kustomization) error {
 	gvk := kustomizev1.GroupVersion.WithKind("Kustomization")
 	export := kustomizev1.Kustomization{
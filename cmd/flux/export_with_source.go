@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+// addExportWithSourceFlag adds the --with-source flag to the export
+// commands of the resources that consume sources.
+func addExportWithSourceFlag(cmd *cobra.Command, withSource *bool) {
+	cmd.Flags().BoolVar(withSource, "with-source", false,
+		"include the referenced source in the output, secrets and config maps are listed as comments but never exported")
+}
+
+// objectReference identifies a secret or config map that an exported
+// object depends on, along with what it's used for.
+type objectReference struct {
+	kind      string
+	namespace string
+	name      string
+	usage     string
+}
+
+// printReferences writes the secrets and config maps referenced by an
+// exported object as YAML comments, so that they can be provisioned
+// before the object is imported in another cluster.
+func printReferences(kind string, owner types.NamespacedName, refs []objectReference) {
+	for _, ref := range refs {
+		fmt.Printf("# %s %s references %s %s/%s (%s), which is not exported\n",
+			kind, owner, ref.kind, ref.namespace, ref.name, ref.usage)
+	}
+}
+
+func secretReference(namespace string, ref *meta.LocalObjectReference, usage string) []objectReference {
+	if ref == nil || ref.Name == "" {
+		return nil
+	}
+	return []objectReference{{kind: "Secret", namespace: namespace, name: ref.Name, usage: usage}}
+}
+
+// sourceExporter exports the sources referenced by Kustomizations and
+// HelmReleases, ahead of the objects that reference them and at most
+// once per source.
+type sourceExporter struct {
+	kubeClient client.Client
+	exported   map[string]bool
+}
+
+func newSourceExporter(kubeClient client.Client) *sourceExporter {
+	return &sourceExporter{
+		kubeClient: kubeClient,
+		exported:   map[string]bool{},
+	}
+}
+
+func (e *sourceExporter) export(ctx context.Context, kind, namespace, name string) error {
+	key := fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+	if e.exported[key] {
+		return nil
+	}
+	e.exported[key] = true
+
+	namespacedName := types.NamespacedName{Namespace: namespace, Name: name}
+	switch kind {
+	case sourcev1.GitRepositoryKind:
+		var source sourcev1.GitRepository
+		if err := e.kubeClient.Get(ctx, namespacedName, &source); err != nil {
+			return fmt.Errorf("failed to retrieve %s %s: %w", kind, namespacedName, err)
+		}
+		printReferences(kind, namespacedName, secretReference(namespace, source.Spec.SecretRef, "credentials"))
+		return exportGit(source)
+	case sourcev1.HelmRepositoryKind:
+		var source sourcev1.HelmRepository
+		if err := e.kubeClient.Get(ctx, namespacedName, &source); err != nil {
+			return fmt.Errorf("failed to retrieve %s %s: %w", kind, namespacedName, err)
+		}
+		printReferences(kind, namespacedName, secretReference(namespace, source.Spec.SecretRef, "credentials"))
+		return exportHelmRepository(source)
+	case sourcev1.BucketKind:
+		var source sourcev1.Bucket
+		if err := e.kubeClient.Get(ctx, namespacedName, &source); err != nil {
+			return fmt.Errorf("failed to retrieve %s %s: %w", kind, namespacedName, err)
+		}
+		printReferences(kind, namespacedName, secretReference(namespace, source.Spec.SecretRef, "credentials"))
+		return exportBucket(source)
+	default:
+		return fmt.Errorf("source kind '%s' is not supported", kind)
+	}
+}
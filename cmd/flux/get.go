@@ -18,36 +18,82 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/fluxcd/pkg/apis/meta"
 
+	"github.com/fluxcd/flux2/internal/flags"
 	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var getCmd = &cobra.Command{
-	Use:   "get",
-	Short: "Get sources and resources",
-	Long:  "The get sub-commands print the statuses of sources and resources.",
+	Use:               "get",
+	Short:             "Get sources and resources",
+	Long:              "The get sub-commands print the statuses of sources and resources.",
+	PersistentPreRunE: validateGetFlags,
 }
 
 type GetFlags struct {
-	allNamespaces bool
+	allNamespaces   bool
+	output          flags.Output
+	count           bool
+	exitCodeOnEmpty bool
+	statusSelector  []string
+	chunkSize       int64
+	maxConcurrency  int
 }
 
-var getArgs GetFlags
+var getArgs = NewGetFlags()
 
 func init() {
 	getCmd.PersistentFlags().BoolVarP(&getArgs.allNamespaces, "all-namespaces", "A", false,
 		"list the requested object(s) across all namespaces")
+	getCmd.PersistentFlags().VarP(&getArgs.output, "output", "o", getArgs.output.Description())
+	getCmd.PersistentFlags().BoolVar(&getArgs.count, "count", false,
+		"print only the number of matching objects, takes precedence over --output")
+	getCmd.PersistentFlags().BoolVar(&getArgs.exitCodeOnEmpty, "exit-code-on-empty", false,
+		"exit with a non-zero code when no objects match")
+	getCmd.PersistentFlags().StringSliceVar(&getArgs.statusSelector, "status-selector", nil,
+		"filter objects by the value of a status column, in the format '<column>=<value>' e.g. 'ready=false'")
+	getCmd.PersistentFlags().Int64Var(&getArgs.chunkSize, "chunk-size", getArgs.chunkSize,
+		"list the objects in pages of this size, 0 disables pagination")
+	getCmd.PersistentFlags().IntVar(&getArgs.maxConcurrency, "max-concurrency", getArgs.maxConcurrency,
+		"list the namespaces separately with --all-namespaces, with at most this number of namespaces listed in parallel, 0 lists the objects cluster-wide")
 	rootCmd.AddCommand(getCmd)
 }
 
+func NewGetFlags() GetFlags {
+	return GetFlags{
+		output:    flags.Output{Format: utils.OutputTable},
+		chunkSize: 500,
+	}
+}
+
+// validateGetFlags rejects the flags which only apply to the summary
+// rows when the objects are printed with a template instead.
+func validateGetFlags(cmd *cobra.Command, args []string) error {
+	if getTableOutput() {
+		return nil
+	}
+	if len(getArgs.statusSelector) > 0 {
+		return fmt.Errorf("--status-selector can't be used with --output %s", getArgs.output.Format)
+	}
+	if getArgs.exitCodeOnEmpty {
+		return fmt.Errorf("--exit-code-on-empty can't be used with --output %s", getArgs.output.Format)
+	}
+	return nil
+}
+
 type summarisable interface {
 	listAdapter
 	summariseItem(i int, includeNamespace bool) []string
@@ -72,16 +118,35 @@ func nameColumns(item named, includeNamespace bool) []string {
 
 var namespaceHeader = []string{"Namespace"}
 
+// secretStatus tells if a secret referenced by an object can be resolved,
+// and if it contains the given key when one is required.
+func secretStatus(ctx context.Context, kubeClient client.Client, namespace, name, key string) string {
+	if name == "" {
+		return "-"
+	}
+	var secret corev1.Secret
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "NotFound"
+		}
+		return "Unknown"
+	}
+	if _, ok := secret.Data[key]; key != "" && !ok {
+		return "MissingKey"
+	}
+	return "Found"
+}
+
 type getCommand struct {
 	apiType
 	list summarisable
 }
 
 func (get getCommand) run(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -90,14 +155,13 @@ func (get getCommand) run(cmd *cobra.Command, args []string) error {
 	if !getArgs.allNamespaces {
 		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
 	}
-	err = kubeClient.List(ctx, get.list.asClientList(), listOpts...)
+	err = listGetObjects(ctx, kubeClient, get.list.asClientList(), listOpts...)
 	if err != nil {
 		return err
 	}
 
-	if get.list.len() == 0 {
-		logger.Failuref("no %s objects found in %s namespace", get.kind, rootArgs.namespace)
-		return nil
+	if !getTableOutput() {
+		return utils.PrintTemplate(os.Stdout, getArgs.output.Format, getArgs.output.Template, get.list.asClientList())
 	}
 
 	header := get.list.headers(getArgs.allNamespaces)
@@ -106,6 +170,87 @@ func (get getCommand) run(cmd *cobra.Command, args []string) error {
 		row := get.list.summariseItem(i, getArgs.allNamespaces)
 		rows = append(rows, row)
 	}
-	utils.PrintTable(os.Stdout, header, rows)
+	return printGetRows(fmt.Sprintf("%s objects", get.kind), header, rows)
+}
+
+// getTableOutput tells if the listed objects should be summarised in
+// rows, which is the case for the table output and for --count.
+func getTableOutput() bool {
+	return getArgs.count || getArgs.output.Format == utils.OutputTable
+}
+
+// printGetRows filters the summary rows with the --status-selector
+// flag, then prints them as a table or, with --count, prints only their
+// number. It returns an error when no rows are left and
+// --exit-code-on-empty is set.
+func printGetRows(kind string, header []string, rows [][]string) error {
+	rows, err := selectRows(header, rows, getArgs.statusSelector)
+	if err != nil {
+		return err
+	}
+
+	if getArgs.count {
+		fmt.Println(len(rows))
+	} else if len(rows) > 0 {
+		statusColumn := -1
+		for i, h := range header {
+			if h == "Ready" {
+				statusColumn = i
+			}
+		}
+		utils.PrintStatusTable(os.Stdout, header, rows, statusColumn)
+	}
+
+	if len(rows) == 0 {
+		if getArgs.exitCodeOnEmpty {
+			return fmt.Errorf("no %s found in %s namespace", kind, rootArgs.namespace)
+		}
+		if !getArgs.count {
+			logger.Failuref("no %s found in %s namespace", kind, rootArgs.namespace)
+		}
+	}
 	return nil
 }
+
+// selectRows returns the rows for which the columns named in the
+// selectors, matched case-insensitively against the header, have the
+// given values.
+func selectRows(header []string, rows [][]string, selectors []string) ([][]string, error) {
+	if len(selectors) == 0 {
+		return rows, nil
+	}
+
+	columns := map[int]string{}
+	for _, selector := range selectors {
+		parts := strings.SplitN(selector, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid status selector '%s', must be in the format <column>=<value>", selector)
+		}
+		index := -1
+		for i, h := range header {
+			if strings.EqualFold(h, parts[0]) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("invalid status selector '%s', column '%s' not found in %s", selector, parts[0], strings.Join(header, ", "))
+		}
+		columns[index] = parts[1]
+	}
+
+	var selected [][]string
+	for _, row := range rows {
+		match := true
+		for index, value := range columns {
+			if index >= len(row) || !strings.EqualFold(row[index], value) {
+				match = false
+				break
+			}
+		}
+		if match {
+			selected = append(selected, row)
+		}
+	}
+	return selected, nil
+}
@@ -23,8 +23,10 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/fluxcd/flux2/internal/utils"
@@ -47,10 +49,10 @@ func init() {
 }
 
 func getAlertCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -60,17 +62,16 @@ func getAlertCmdRun(cmd *cobra.Command, args []string) error {
 		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
 	}
 	var list notificationv1.AlertList
-	err = kubeClient.List(ctx, &list, listOpts...)
+	err = listGetObjects(ctx, kubeClient, &list, listOpts...)
 	if err != nil {
 		return err
 	}
 
-	if len(list.Items) == 0 {
-		logger.Failuref("no alerts found in %s namespace", rootArgs.namespace)
-		return nil
+	if !getTableOutput() {
+		return utils.PrintTemplate(os.Stdout, getArgs.output.Format, getArgs.output.Template, &list)
 	}
 
-	header := []string{"Name", "Ready", "Message", "Suspended"}
+	header := []string{"Name", "Ready", "Message", "Suspended", "Provider Ready"}
 	if getArgs.allNamespaces {
 		header = append([]string{"Namespace"}, header...)
 	}
@@ -92,11 +93,32 @@ func getAlertCmdRun(cmd *cobra.Command, args []string) error {
 				strings.Title(strconv.FormatBool(alert.Spec.Suspend)),
 			}
 		}
+		row = append(row, alertProviderStatus(ctx, kubeClient, alert))
 		if getArgs.allNamespaces {
 			row = append([]string{alert.Namespace}, row...)
 		}
 		rows = append(rows, row)
 	}
-	utils.PrintTable(os.Stdout, header, rows)
-	return nil
+	return printGetRows("alerts", header, rows)
+}
+
+// alertProviderStatus returns the readiness of the provider events are
+// dispatched to, an alert with a missing or failing provider silently
+// drops its events.
+func alertProviderStatus(ctx context.Context, kubeClient client.Client, alert notificationv1.Alert) string {
+	var provider notificationv1.Provider
+	namespacedName := types.NamespacedName{
+		Namespace: alert.Namespace,
+		Name:      alert.Spec.ProviderRef.Name,
+	}
+	if err := kubeClient.Get(ctx, namespacedName, &provider); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "NotFound"
+		}
+		return "Unknown"
+	}
+	if c := apimeta.FindStatusCondition(provider.Status.Conditions, meta.ReadyCondition); c != nil {
+		return string(c.Status)
+	}
+	return string(metav1.ConditionUnknown)
 }
@@ -45,10 +45,10 @@ func init() {
 }
 
 func getAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -58,17 +58,16 @@ func getAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
 	}
 	var list notificationv1.ProviderList
-	err = kubeClient.List(ctx, &list, listOpts...)
+	err = listGetObjects(ctx, kubeClient, &list, listOpts...)
 	if err != nil {
 		return err
 	}
 
-	if len(list.Items) == 0 {
-		logger.Failuref("no providers found in %s namespace", rootArgs.namespace)
-		return nil
+	if !getTableOutput() {
+		return utils.PrintTemplate(os.Stdout, getArgs.output.Format, getArgs.output.Template, &list)
 	}
 
-	header := []string{"Name", "Ready", "Message"}
+	header := []string{"Name", "Ready", "Message", "Secret"}
 	if getArgs.allNamespaces {
 		header = append([]string{"Namespace"}, header...)
 	}
@@ -88,11 +87,15 @@ func getAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 				"waiting to be reconciled",
 			}
 		}
+		var secretName string
+		if provider.Spec.SecretRef != nil {
+			secretName = provider.Spec.SecretRef.Name
+		}
+		row = append(row, secretStatus(ctx, kubeClient, provider.Namespace, secretName, ""))
 		if getArgs.allNamespaces {
 			row = append([]string{provider.Namespace}, row...)
 		}
 		rows = append(rows, row)
 	}
-	utils.PrintTable(os.Stdout, header, rows)
-	return nil
+	return printGetRows("providers", header, rows)
 }
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fluxcd/pkg/runtime/dependency"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+type dependsCheckFlags struct {
+	enabled              bool
+	noCrossNamespaceRefs bool
+}
+
+var dependsCheckArgs dependsCheckFlags
+
+func addDependsCheckFlags(cmd *cobra.Command, kind string) {
+	cmd.Flags().BoolVar(&dependsCheckArgs.enabled, "depends-graph-check", false,
+		fmt.Sprintf("validate the dependsOn references of the %s in all namespaces instead of listing them, "+
+			"exits with an error if references are missing or circular", kind))
+	cmd.Flags().BoolVar(&dependsCheckArgs.noCrossNamespaceRefs, "no-cross-namespace-refs", false,
+		"report dependencies on objects in other namespaces as problems, for clusters where they are blocked")
+}
+
+// checkDependencyGraph prints the problems found in the dependsOn references
+// of the objects: missing targets, cross-namespace references when they are
+// not allowed, and circular dependencies.
+func checkDependencyGraph(kind string, items []dependent) error {
+	exists := make(map[string]bool)
+	for _, item := range items {
+		exists[item.namespacedName.String()] = true
+	}
+
+	var rows [][]string
+	for _, item := range items {
+		for _, ref := range item.dependsOn {
+			target := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+			if target.Namespace == "" {
+				target.Namespace = item.namespacedName.Namespace
+			}
+			switch {
+			case target == item.namespacedName:
+				rows = append(rows, []string{item.namespacedName.Namespace, item.namespacedName.Name,
+					"depends on itself"})
+			case !exists[target.String()]:
+				rows = append(rows, []string{item.namespacedName.Namespace, item.namespacedName.Name,
+					fmt.Sprintf("dependency %s not found", target)})
+			}
+			if dependsCheckArgs.noCrossNamespaceRefs && target.Namespace != item.namespacedName.Namespace {
+				rows = append(rows, []string{item.namespacedName.Namespace, item.namespacedName.Name,
+					fmt.Sprintf("cross-namespace dependency %s is not allowed", target)})
+			}
+		}
+	}
+
+	dependents := make([]dependency.Dependent, len(items))
+	for i, item := range items {
+		dependents[i] = item
+	}
+	if _, err := dependency.Sort(dependents); err != nil {
+		var circular dependency.CircularDependencyError
+		if !errors.As(err, &circular) {
+			return err
+		}
+		for _, chain := range circular {
+			sort.Strings(chain)
+			for _, ref := range chain {
+				parts := strings.SplitN(ref, string(types.Separator), 2)
+				rows = append(rows, []string{parts[0], parts[1],
+					fmt.Sprintf("circular dependency between %s", strings.Join(chain, ", "))})
+			}
+		}
+	}
+
+	if len(rows) == 0 {
+		logger.Successf("no dependency problems found in %d %s", len(items), kind)
+		return nil
+	}
+	utils.PrintTable(os.Stdout, []string{"Namespace", "Name", "Problem"}, rows)
+	return fmt.Errorf("found %d dependency problems in %s", len(rows), kind)
+}
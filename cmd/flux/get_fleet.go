@@ -0,0 +1,322 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var getFleetCmd = &cobra.Command{
+	Use:   "fleet",
+	Short: "Get the Flux status of the workload clusters registered on a hub cluster",
+	Long: `The get fleet command discovers the workload clusters from the kubeconfig secrets of a hub cluster,
+e.g. the ones generated by Cluster API, and prints a consolidated report of the Flux controllers and objects of each cluster.
+The workload clusters are only read from.`,
+	Example: `  # Report the status of all the Cluster API workload clusters
+  flux get fleet --all-namespaces
+
+  # List the clusters with failures and their failing objects
+  flux get fleet -A --status-selector ready=false --show-failures
+
+  # Discover the clusters from Rancher Fleet kubeconfig secrets
+  flux get fleet -n fleet-default --secret-type=Opaque --secret-selector=fleet.cattle.io/managed=true
+`,
+	RunE: getFleetCmdRun,
+}
+
+type getFleetFlags struct {
+	secretType        string
+	secretSelector    string
+	secretKey         string
+	workloadNamespace string
+	showFailures      bool
+}
+
+var getFleetArgs = getFleetFlags{
+	secretType: "cluster.x-k8s.io/secret",
+	secretKey:  "value",
+}
+
+func init() {
+	getFleetCmd.Flags().StringVar(&getFleetArgs.secretType, "secret-type", getFleetArgs.secretType,
+		"type of the kubeconfig secrets, the Cluster API convention is used by default")
+	getFleetCmd.Flags().StringVar(&getFleetArgs.secretSelector, "secret-selector", "",
+		"label selector of the kubeconfig secrets")
+	getFleetCmd.Flags().StringVar(&getFleetArgs.secretKey, "secret-key", getFleetArgs.secretKey,
+		"key of the kubeconfig in the secrets, secrets without it are skipped")
+	getFleetCmd.Flags().StringVar(&getFleetArgs.workloadNamespace, "workload-namespace", rootArgs.defaults.Namespace,
+		"namespace of the Flux controllers on the workload clusters")
+	getFleetCmd.Flags().BoolVar(&getFleetArgs.showFailures, "show-failures", false,
+		"print the failing objects of each cluster after the report")
+	getCmd.AddCommand(getFleetCmd)
+}
+
+// fleetCluster is the report of a workload cluster.
+type fleetCluster struct {
+	Name           string   `json:"name"`
+	Ready          bool     `json:"ready"`
+	Message        string   `json:"message"`
+	Controllers    string   `json:"controllers"`
+	Kustomizations string   `json:"kustomizations"`
+	HelmReleases   string   `json:"helmReleases"`
+	Sources        string   `json:"sources"`
+	Failures       []string `json:"failures,omitempty"`
+}
+
+// fleetKinds are the kinds counted in the report, listed in their served
+// version so that the Flux CRDs don't need to be in the client scheme.
+var fleetKinds = []struct {
+	column string
+	gvk    schema.GroupVersionKind
+}{
+	{"kustomizations", kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)},
+	{"helmReleases", helmv2.GroupVersion.WithKind(helmv2.HelmReleaseKind)},
+	{"sources", sourcev1.GroupVersion.WithKind(sourcev1.GitRepositoryKind)},
+	{"sources", sourcev1.GroupVersion.WithKind(sourcev1.HelmRepositoryKind)},
+	{"sources", sourcev1.GroupVersion.WithKind(sourcev1.BucketKind)},
+}
+
+func getFleetCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+
+	var listOpts []client.ListOption
+	if !getArgs.allNamespaces {
+		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
+	}
+	if getFleetArgs.secretSelector != "" {
+		selector, err := metav1.ParseToLabelSelector(getFleetArgs.secretSelector)
+		if err != nil {
+			return fmt.Errorf("invalid secret selector: %w", err)
+		}
+		s, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return fmt.Errorf("invalid secret selector: %w", err)
+		}
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: s})
+	}
+	var secrets corev1.SecretList
+	if err := listGetObjects(ctx, kubeClient, &secrets, listOpts...); err != nil {
+		return err
+	}
+
+	var kubeconfigs []corev1.Secret
+	for _, secret := range secrets.Items {
+		if string(secret.Type) != getFleetArgs.secretType {
+			continue
+		}
+		if _, ok := secret.Data[getFleetArgs.secretKey]; ok {
+			kubeconfigs = append(kubeconfigs, secret)
+		}
+	}
+
+	clusters := make([]fleetCluster, len(kubeconfigs))
+	sem := make(chan struct{}, getArgs.maxConcurrency)
+	var wg sync.WaitGroup
+	for i, secret := range kubeconfigs {
+		wg.Add(1)
+		go func(i int, secret corev1.Secret) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			clusters[i] = fleetClusterReport(ctx, secret)
+		}(i, secret)
+	}
+	wg.Wait()
+
+	if !getTableOutput() {
+		report := struct {
+			Items []fleetCluster `json:"items"`
+		}{clusters}
+		return utils.PrintTemplate(os.Stdout, getArgs.output.Format, getArgs.output.Template, report)
+	}
+
+	header := []string{"Cluster", "Ready", "Message", "Controllers", "Kustomizations", "HelmReleases", "Sources"}
+	var rows [][]string
+	for _, c := range clusters {
+		ready := string(metav1.ConditionTrue)
+		if !c.Ready {
+			ready = string(metav1.ConditionFalse)
+		}
+		rows = append(rows, []string{c.Name, ready, c.Message, c.Controllers, c.Kustomizations, c.HelmReleases, c.Sources})
+	}
+	if err := printGetRows("clusters", header, rows); err != nil {
+		return err
+	}
+
+	if getFleetArgs.showFailures && !getArgs.count {
+		var failures [][]string
+		for _, c := range clusters {
+			for _, f := range c.Failures {
+				failures = append(failures, []string{c.Name, f})
+			}
+		}
+		if len(failures) > 0 {
+			fmt.Println()
+			utils.PrintTable(os.Stdout, []string{"Cluster", "Failure"}, failures)
+		}
+	}
+	return nil
+}
+
+// fleetRESTConfig loads a kubeconfig read from a hub secret. Only inlined
+// token and certificate credentials are allowed: the exec and auth-provider
+// plugins would run commands chosen by whoever can write the secret on the
+// machine of the operator, and file references would send local files to
+// the server of the kubeconfig.
+func fleetRESTConfig(data []byte) (*rest.Config, error) {
+	kubeconfig, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	for name, authInfo := range kubeconfig.AuthInfos {
+		if authInfo.Exec != nil {
+			return nil, fmt.Errorf("kubeconfig user '%s' uses an exec plugin, only token and certificate auth are allowed", name)
+		}
+		if authInfo.AuthProvider != nil {
+			return nil, fmt.Errorf("kubeconfig user '%s' uses an auth provider, only token and certificate auth are allowed", name)
+		}
+		if authInfo.TokenFile != "" || authInfo.ClientCertificate != "" || authInfo.ClientKey != "" {
+			return nil, fmt.Errorf("kubeconfig user '%s' references local files, the credentials must be inlined", name)
+		}
+	}
+	for name, cluster := range kubeconfig.Clusters {
+		if cluster.CertificateAuthority != "" {
+			return nil, fmt.Errorf("kubeconfig cluster '%s' references a local file, the CA must be inlined", name)
+		}
+	}
+	cfg, err := clientcmd.NewDefaultClientConfig(*kubeconfig, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	return cfg, nil
+}
+
+// fleetClusterReport connects to a workload cluster with the kubeconfig
+// from the secret and summarises its Flux controllers and objects.
+func fleetClusterReport(ctx context.Context, secret corev1.Secret) fleetCluster {
+	report := fleetCluster{Name: fmt.Sprintf("%s/%s", secret.Namespace, secret.Name)}
+	fail := func(err error) fleetCluster {
+		report.Message = err.Error()
+		return report
+	}
+
+	cfg, err := fleetRESTConfig(secret.Data[getFleetArgs.secretKey])
+	if err != nil {
+		return fail(err)
+	}
+	kubeClient, err := utils.KubeClientForConfig(cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	var deployments unstructured.UnstructuredList
+	deployments.SetGroupVersionKind(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DeploymentList"})
+	if err := kubeClient.List(ctx, &deployments, client.InNamespace(getFleetArgs.workloadNamespace)); err != nil {
+		return fail(fmt.Errorf("unreachable: %w", err))
+	}
+	readyDeployments := 0
+	for _, d := range deployments.Items {
+		replicas, found, _ := unstructured.NestedInt64(d.Object, "spec", "replicas")
+		if !found {
+			// the API server defaults the replicas to 1
+			replicas = 1
+		}
+		ready, _, _ := unstructured.NestedInt64(d.Object, "status", "readyReplicas")
+		if ready >= replicas {
+			readyDeployments++
+		} else {
+			report.Failures = append(report.Failures, fmt.Sprintf("Deployment/%s/%s: %d/%d replicas ready",
+				d.GetNamespace(), d.GetName(), ready, replicas))
+		}
+	}
+	report.Controllers = fmt.Sprintf("%d/%d", readyDeployments, len(deployments.Items))
+
+	ready := map[string]int{}
+	total := map[string]int{}
+	for _, k := range fleetKinds {
+		var list unstructured.UnstructuredList
+		list.SetGroupVersionKind(k.gvk.GroupVersion().WithKind(k.gvk.Kind + "List"))
+		if err := kubeClient.List(ctx, &list); err != nil {
+			if apimeta.IsNoMatchError(err) {
+				continue
+			}
+			return fail(fmt.Errorf("listing %s objects failed: %w", k.gvk.Kind, err))
+		}
+		for _, item := range list.Items {
+			total[k.column]++
+			var conditions []metav1.Condition
+			if raw, ok, _ := unstructured.NestedSlice(item.Object, "status", "conditions"); ok {
+				for _, r := range raw {
+					m, _ := r.(map[string]interface{})
+					t, _ := m["type"].(string)
+					s, _ := m["status"].(string)
+					msg, _ := m["message"].(string)
+					conditions = append(conditions, metav1.Condition{Type: t, Status: metav1.ConditionStatus(s), Message: msg})
+				}
+			}
+			if suspend, _, _ := unstructured.NestedBool(item.Object, "spec", "suspend"); suspend ||
+				apimeta.IsStatusConditionTrue(conditions, meta.ReadyCondition) {
+				ready[k.column]++
+				continue
+			}
+			status, message := statusAndMessage(conditions)
+			report.Failures = append(report.Failures, fmt.Sprintf("%s/%s/%s: %s %s",
+				k.gvk.Kind, item.GetNamespace(), item.GetName(), status, message))
+		}
+	}
+	report.Kustomizations = fmt.Sprintf("%d/%d", ready["kustomizations"], total["kustomizations"])
+	report.HelmReleases = fmt.Sprintf("%d/%d", ready["helmReleases"], total["helmReleases"])
+	report.Sources = fmt.Sprintf("%d/%d", ready["sources"], total["sources"])
+
+	sort.Strings(report.Failures)
+	switch {
+	case len(deployments.Items) == 0:
+		report.Message = fmt.Sprintf("no controllers found in %s namespace", getFleetArgs.workloadNamespace)
+	case len(report.Failures) > 0:
+		report.Message = fmt.Sprintf("%d failures, %s", len(report.Failures), report.Failures[0])
+	default:
+		report.Ready = true
+	}
+	return report
+}
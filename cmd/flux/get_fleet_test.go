@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestFleetRESTConfig(t *testing.T) {
+	kubeconfig := func(user string) []byte {
+		return []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: workload
+  cluster:
+    server: https://workload.example.com:6443
+contexts:
+- name: workload
+  context:
+    cluster: workload
+    user: admin
+current-context: workload
+users:
+- name: admin
+  user:
+` + user)
+	}
+	tests := []struct {
+		name      string
+		data      []byte
+		expectErr bool
+	}{
+		{"token", kubeconfig("    token: abc\n"), false},
+		{"client certificate data", kubeconfig("    client-certificate-data: Y2VydA==\n    client-key-data: a2V5\n"), false},
+		{"exec plugin", kubeconfig("    exec:\n      apiVersion: client.authentication.k8s.io/v1beta1\n      command: sh\n      args: [\"-c\", \"id\"]\n"), true},
+		{"auth provider", kubeconfig("    auth-provider:\n      name: gcp\n"), true},
+		{"token file", kubeconfig("    tokenFile: /etc/passwd\n"), true},
+		{"client certificate file", kubeconfig("    client-certificate: /home/user/cert.pem\n"), true},
+		{"invalid", []byte("{"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := fleetRESTConfig(tt.data)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("fleetRESTConfig() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if err == nil && cfg.Host != "https://workload.example.com:6443" {
+				t.Errorf("fleetRESTConfig() host = %s", cfg.Host)
+			}
+		})
+	}
+}
@@ -17,7 +17,14 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -26,8 +33,14 @@ import (
 	"github.com/fluxcd/pkg/apis/meta"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
@@ -40,43 +53,96 @@ var getHelmReleaseCmd = &cobra.Command{
 	Long:    "The get helmreleases command prints the statuses of the resources.",
 	Example: `  # List all Helm releases and their status
   flux get helmreleases
+
+  # List all Helm releases and the objects that have drifted from the Helm storage
+  flux get helmreleases --show-drift
+
+  # Print the values of a Helm release merged from valuesFrom and inline values
+  flux get helmreleases podinfo --values
+
+  # Print the merged values including the ones coming from secrets
+  flux get helmreleases podinfo --values --show-secrets
 `,
 	RunE: getHelmReleaseCmdRun,
 }
 
+type getHelmReleaseFlags struct {
+	showDrift   bool
+	values      bool
+	showSecrets bool
+}
+
+var ghrArgs getHelmReleaseFlags
+
 func init() {
+	getHelmReleaseCmd.Flags().BoolVar(&ghrArgs.showDrift, "show-drift", false,
+		"compare the objects of each release with the manifest stored by Helm and list the ones changed or removed out-of-band")
+	getHelmReleaseCmd.Flags().BoolVar(&ghrArgs.values, "values", false,
+		"print the values of the releases, optionally filtered by name, as merged by helm-controller from valuesFrom and inline values")
+	getHelmReleaseCmd.Flags().BoolVar(&ghrArgs.showSecrets, "show-secrets", false,
+		"print the values coming from secrets in clear text instead of masking them, used with --values")
+	addDependsCheckFlags(getHelmReleaseCmd, "HelmReleases")
 	getCmd.AddCommand(getHelmReleaseCmd)
 }
 
 func getHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
 
 	var listOpts []client.ListOption
-	if !getArgs.allNamespaces {
+	if !getArgs.allNamespaces && !dependsCheckArgs.enabled {
 		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
 	}
 	var list helmv2.HelmReleaseList
-	err = kubeClient.List(ctx, &list, listOpts...)
+	err = listGetObjects(ctx, kubeClient, &list, listOpts...)
 	if err != nil {
 		return err
 	}
 
-	if len(list.Items) == 0 {
-		logger.Failuref("no releases found in %s namespace", rootArgs.namespace)
-		return nil
+	if dependsCheckArgs.enabled {
+		var items []dependent
+		for _, item := range list.Items {
+			items = append(items, dependent{
+				namespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name},
+				dependsOn:      item.Spec.DependsOn,
+			})
+		}
+		return checkDependencyGraph("HelmReleases", items)
+	}
+
+	if ghrArgs.values {
+		releases := list.Items
+		if len(args) > 0 {
+			releases = nil
+			for _, hr := range list.Items {
+				if utils.ContainsItemString(args, hr.Name) {
+					releases = append(releases, hr)
+				}
+			}
+		}
+		if len(releases) == 0 {
+			return fmt.Errorf("no releases found in %s namespace", rootArgs.namespace)
+		}
+		return printHelmReleaseValues(ctx, kubeClient, releases, ghrArgs.showSecrets)
+	}
+
+	if !getTableOutput() {
+		return utils.PrintTemplate(os.Stdout, getArgs.output.Format, getArgs.output.Template, &list)
 	}
 
 	header := []string{"Name", "Ready", "Message", "Revision", "Suspended"}
+	if ghrArgs.showDrift {
+		header = append(header, "Drifted")
+	}
 	if getArgs.allNamespaces {
 		header = append([]string{"Namespace"}, header...)
 	}
-	var rows [][]string
+	var rows, driftRows [][]string
 	for _, helmRelease := range list.Items {
 		row := []string{}
 		if c := apimeta.FindStatusCondition(helmRelease.Status.Conditions, meta.ReadyCondition); c != nil {
@@ -96,11 +162,238 @@ func getHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 				strings.Title(strconv.FormatBool(helmRelease.Spec.Suspend)),
 			}
 		}
+		if ghrArgs.showDrift {
+			// the Helm storage and the objects of remote releases are
+			// in another cluster
+			remote := helmRelease.Spec.KubeConfig != nil
+			var drifted [][2]string
+			var err error
+			if !remote {
+				drifted, err = helmReleaseDrift(ctx, kubeClient, helmRelease)
+			}
+			switch {
+			case remote:
+				row = append(row, "Remote")
+			case err != nil:
+				logger.Failuref("drift detection failed for %s/%s: %s", helmRelease.Namespace, helmRelease.Name, err.Error())
+				row = append(row, "Unknown")
+			case len(drifted) > 0:
+				row = append(row, "True")
+				for _, d := range drifted {
+					driftRows = append(driftRows, []string{helmRelease.Namespace, helmRelease.Name, d[0], d[1]})
+				}
+			default:
+				row = append(row, "False")
+			}
+		}
 		if getArgs.allNamespaces {
 			row = append([]string{helmRelease.Namespace}, row...)
 		}
 		rows = append(rows, row)
 	}
-	utils.PrintTable(os.Stdout, header, rows)
+	if err := printGetRows("releases", header, rows); err != nil {
+		return err
+	}
+
+	if len(driftRows) > 0 && !getArgs.count {
+		fmt.Println()
+		utils.PrintTable(os.Stdout, []string{"Namespace", "HelmRelease", "Object", "Drift"}, driftRows)
+	}
 	return nil
 }
+
+// helmReleaseDrift compares the objects of the last release made by
+// helm-controller, as recorded in the Helm storage, with their live
+// state. It returns a list of object references paired with the kind
+// of drift ("modified" or "deleted").
+func helmReleaseDrift(ctx context.Context, kubeClient client.Client, hr helmv2.HelmRelease) ([][2]string, error) {
+	if hr.Status.LastReleaseRevision == 0 {
+		return nil, nil
+	}
+
+	namespace := hr.GetReleaseNamespace()
+	storageName := fmt.Sprintf("sh.helm.release.v1.%s.v%d", hr.GetReleaseName(), hr.Status.LastReleaseRevision)
+	var storage corev1.Secret
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: storageName}, &storage); err != nil {
+		return nil, fmt.Errorf("unable to read Helm storage: %w", err)
+	}
+
+	manifest, err := decodeHelmReleaseManifest(storage.Data["release"])
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode Helm storage %s: %w", storageName, err)
+	}
+
+	var drifted [][2]string
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 2048)
+	for {
+		var obj map[string]interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+
+		desired := &unstructured.Unstructured{Object: obj}
+		gvk := desired.GroupVersionKind()
+		mapping, err := kubeClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+		if mapping.Scope.Name() == apimeta.RESTScopeNameNamespace && desired.GetNamespace() == "" {
+			desired.SetNamespace(namespace)
+		}
+		ref := fmt.Sprintf("%s/%s", gvk.Kind, desired.GetName())
+		if desired.GetNamespace() != "" {
+			ref = fmt.Sprintf("%s/%s/%s", gvk.Kind, desired.GetNamespace(), desired.GetName())
+		}
+
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(gvk)
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(desired), live); err != nil {
+			if apierrors.IsNotFound(err) {
+				drifted = append(drifted, [2]string{ref, "deleted"})
+				continue
+			}
+			return nil, err
+		}
+		if gvk.Group == "" && gvk.Kind == "Secret" {
+			encodeSecretStringData(desired.Object)
+		}
+		if !isSubset(desired.Object, live.Object) {
+			drifted = append(drifted, [2]string{ref, "modified"})
+		}
+	}
+	return drifted, nil
+}
+
+// decodeHelmReleaseManifest extracts the rendered manifest from the
+// release data of a Helm storage secret, which is a base64 encoded
+// and gzipped JSON document.
+func decodeHelmReleaseManifest(data []byte) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return "", err
+	}
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b, 0x08}) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return "", err
+		}
+	}
+
+	var release struct {
+		Manifest string `json:"manifest"`
+	}
+	if err := json.Unmarshal(b, &release); err != nil {
+		return "", err
+	}
+	return release.Manifest, nil
+}
+
+// isSubset reports whether every field set in desired has the same
+// value in live. Fields which are only present in live, e.g. defaults
+// or status, are ignored.
+func isSubset(desired, live interface{}) bool {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return live == nil && len(d) == 0
+		}
+		for k, v := range d {
+			if !isSubset(v, l[k]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			return live == nil && len(d) == 0
+		}
+		if len(d) != len(l) {
+			return false
+		}
+		for i := range d {
+			if !isSubset(d[i], l[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return equalScalars(desired, live)
+	}
+}
+
+// equalScalars compares the values of a manifest and of a live object,
+// the API server normalises numbers and resource quantities, e.g. a CPU
+// limit of "1000m" is stored as "1".
+func equalScalars(desired, live interface{}) bool {
+	if desired == live {
+		return true
+	}
+	if d, ok := toFloat(desired); ok {
+		if l, ok := toFloat(live); ok {
+			return d == l
+		}
+	}
+	if d, ok := toQuantity(desired); ok {
+		if l, ok := toQuantity(live); ok {
+			return d.Cmp(l) == 0
+		}
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toQuantity(v interface{}) (resource.Quantity, bool) {
+	var s string
+	switch n := v.(type) {
+	case string:
+		s = n
+	case int64, float64, json.Number:
+		s = fmt.Sprint(n)
+	default:
+		return resource.Quantity{}, false
+	}
+	q, err := resource.ParseQuantity(s)
+	return q, err == nil
+}
+
+// encodeSecretStringData moves the stringData of a Secret manifest to its
+// data, as the API server does when the Secret is written.
+func encodeSecretStringData(secret map[string]interface{}) {
+	stringData, ok := secret["stringData"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	data, ok := secret["data"].(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{}
+	}
+	for k, v := range stringData {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(v)))
+	}
+	secret["data"] = data
+	delete(secret, "stringData")
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+)
+
+func TestIsSubset(t *testing.T) {
+	tests := []struct {
+		name    string
+		desired string
+		live    string
+		expect  bool
+	}{
+		{
+			name: "normalised quantities",
+			desired: `spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: app
+        resources:
+          limits:
+            cpu: 1000m
+            memory: 1024Mi
+          requests:
+            cpu: 1`,
+			live:   `{"spec":{"replicas":2,"strategy":{"type":"RollingUpdate"},"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":"1","memory":"1Gi"},"requests":{"cpu":"1"}}}]}}}}`,
+			expect: true,
+		},
+		{
+			name:    "changed quantity",
+			desired: `spec: {resources: {limits: {memory: 512Mi}}}`,
+			live:    `{"spec":{"resources":{"limits":{"memory":"1Gi"}}}}`,
+			expect:  false,
+		},
+		{
+			name:    "changed replicas",
+			desired: `spec: {replicas: 2}`,
+			live:    `{"spec":{"replicas":3}}`,
+			expect:  false,
+		},
+		{
+			name:    "changed string",
+			desired: `spec: {image: "podinfo:v1"}`,
+			live:    `{"spec":{"image":"podinfo:v2"}}`,
+			expect:  false,
+		},
+		{
+			name:    "removed list item",
+			desired: `spec: {args: ["--level", "info"]}`,
+			live:    `{"spec":{"args":["--level"]}}`,
+			expect:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSubset(decodeTestObject(t, tt.desired), decodeTestObject(t, tt.live)); got != tt.expect {
+				t.Errorf("isSubset() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestEncodeSecretStringData(t *testing.T) {
+	desired := decodeTestObject(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+data:
+  token: dG9rZW4=
+stringData:
+  password: secret`)
+	live := decodeTestObject(t, `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"app","uid":"1"},"data":{"token":"dG9rZW4=","password":"c2VjcmV0"},"type":"Opaque"}`)
+
+	if isSubset(desired, live) {
+		t.Errorf("expected stringData not to match before encoding")
+	}
+	encodeSecretStringData(desired)
+	if !isSubset(desired, live) {
+		t.Errorf("expected the encoded stringData to match, got %v", desired)
+	}
+}
+
+// decodeTestObject decodes the JSON of live objects as the Kubernetes
+// client does, with integers as int64, and the YAML of Helm manifests.
+func decodeTestObject(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var obj map[string]interface{}
+	var err error
+	if strings.HasPrefix(data, "{") {
+		err = utiljson.Unmarshal([]byte(data), &obj)
+	} else {
+		err = utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(data), 2048).Decode(&obj)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+func TestHelmReleaseValuesTargetPath(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "values", Namespace: "default"},
+		Data: map[string]string{
+			"values.yaml": "image:\n  tag: v1\n",
+			"enabled":     "true",
+			"config":      "key: value",
+		},
+	}
+	kubeClient := fake.NewFakeClientWithScheme(scheme, cm)
+
+	hr := helmv2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: helmv2.HelmReleaseSpec{
+			ValuesFrom: []helmv2.ValuesReference{
+				{Kind: "ConfigMap", Name: "values"},
+				{Kind: "ConfigMap", Name: "values", ValuesKey: "enabled", TargetPath: "feature.enabled"},
+				{Kind: "ConfigMap", Name: "values", ValuesKey: "config", TargetPath: "image.config"},
+			},
+		},
+	}
+	values, _, err := helmReleaseValues(context.TODO(), kubeClient, hr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values["feature"].(map[string]interface{})["enabled"]; got != "true" {
+		t.Errorf("feature.enabled = %#v, expect the string \"true\"", got)
+	}
+	image := values["image"].(map[string]interface{})
+	if image["tag"] != "v1" || image["config"] != "key: value" {
+		t.Errorf("image = %#v, expect tag v1 and the raw config string", image)
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+)
+
+const maskedValue = "*****"
+
+// valuesPathSeparator joins the keys of a value path, it can't be part
+// of a YAML key set by users.
+const valuesPathSeparator = "\x00"
+
+// printHelmReleaseValues prints the merged values of each release as
+// a YAML document.
+func printHelmReleaseValues(ctx context.Context, kubeClient client.Client, releases []helmv2.HelmRelease, showSecrets bool) error {
+	for _, hr := range releases {
+		values, fromSecrets, err := helmReleaseValues(ctx, kubeClient, hr)
+		if err != nil {
+			return fmt.Errorf("failed to compose the values of HelmRelease %s/%s: %w", hr.Namespace, hr.Name, err)
+		}
+		if !showSecrets {
+			maskValues("", values, fromSecrets)
+		}
+
+		data, err := yaml.Marshal(values)
+		if err != nil {
+			return err
+		}
+		fmt.Println("---")
+		fmt.Printf("# HelmRelease %s/%s\n", hr.Namespace, hr.Name)
+		fmt.Print(string(data))
+	}
+	return nil
+}
+
+// helmReleaseValues composes the values of a HelmRelease in the same
+// order as helm-controller: the valuesFrom references deep-merged in the
+// order they are listed, then the inline values on top. It also returns
+// the paths of the values that were last set by a Secret.
+func helmReleaseValues(ctx context.Context, kubeClient client.Client, hr helmv2.HelmRelease) (map[string]interface{}, map[string]bool, error) {
+	values := map[string]interface{}{}
+	fromSecrets := map[string]bool{}
+
+	for _, ref := range hr.Spec.ValuesFrom {
+		namespacedName := types.NamespacedName{Namespace: hr.Namespace, Name: ref.Name}
+		var data string
+		var found bool
+		switch ref.Kind {
+		case "ConfigMap":
+			var cm corev1.ConfigMap
+			if err := kubeClient.Get(ctx, namespacedName, &cm); err != nil {
+				if apierrors.IsNotFound(err) && ref.Optional {
+					continue
+				}
+				return nil, nil, fmt.Errorf("could not find %s '%s': %w", ref.Kind, namespacedName, err)
+			}
+			data, found = cm.Data[ref.GetValuesKey()]
+		case "Secret":
+			var secret corev1.Secret
+			if err := kubeClient.Get(ctx, namespacedName, &secret); err != nil {
+				if apierrors.IsNotFound(err) && ref.Optional {
+					continue
+				}
+				return nil, nil, fmt.Errorf("could not find %s '%s': %w", ref.Kind, namespacedName, err)
+			}
+			var raw []byte
+			raw, found = secret.Data[ref.GetValuesKey()]
+			data = string(raw)
+		default:
+			return nil, nil, fmt.Errorf("unsupported values kind '%s'", ref.Kind)
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("missing key '%s' in %s '%s'", ref.GetValuesKey(), ref.Kind, namespacedName)
+		}
+
+		var source map[string]interface{}
+		if ref.TargetPath != "" {
+			// the value is kept as a string, as helm-controller does
+			source = valuesAtPath(ref.TargetPath, data)
+		} else if err := yaml.Unmarshal([]byte(data), &source); err != nil {
+			return nil, nil, fmt.Errorf("unable to read values from key '%s' in %s '%s': %w", ref.GetValuesKey(), ref.Kind, namespacedName, err)
+		}
+		mergeValues(values, source)
+		markValues("", source, ref.Kind == "Secret", fromSecrets)
+	}
+
+	inline := hr.GetValues()
+	mergeValues(values, inline)
+	markValues("", inline, false, fromSecrets)
+	return values, fromSecrets, nil
+}
+
+// valuesAtPath returns a values tree holding the value at the given YAML
+// dot notation path.
+func valuesAtPath(path string, value interface{}) map[string]interface{} {
+	keys := strings.Split(path, ".")
+	for i := len(keys) - 1; i > 0; i-- {
+		value = map[string]interface{}{keys[i]: value}
+	}
+	return map[string]interface{}{keys[0]: value}
+}
+
+// mergeValues deep-merges src into dst, the values of src take
+// precedence and lists are replaced rather than merged, as with Helm.
+func mergeValues(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if srcMap, ok := v.(map[string]interface{}); ok {
+			if dstMap, ok := dst[k].(map[string]interface{}); ok {
+				mergeValues(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// markValues records whether the leaf values of src were set by a Secret.
+func markValues(prefix string, src map[string]interface{}, secret bool, fromSecrets map[string]bool) {
+	for k, v := range src {
+		path := prefix + valuesPathSeparator + k
+		if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+			markValues(path, m, secret, fromSecrets)
+			continue
+		}
+		fromSecrets[path] = secret
+	}
+}
+
+// maskValues replaces the leaf values set by a Secret.
+func maskValues(prefix string, values map[string]interface{}, fromSecrets map[string]bool) {
+	for k, v := range values {
+		path := prefix + valuesPathSeparator + k
+		if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+			maskValues(path, m, fromSecrets)
+			continue
+		}
+		if fromSecrets[path] {
+			values[k] = maskedValue
+		}
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/spf13/cobra"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -39,39 +40,56 @@ var getKsCmd = &cobra.Command{
 	Long:    "The get kustomizations command prints the statuses of the resources.",
 	Example: `  # List all kustomizations and their status
   flux get kustomizations
+
+  # Print the last applied revision of each kustomization
+  flux get kustomizations -o jsonpath='{range .items[*]}{.metadata.name} {.status.lastAppliedRevision}{"\n"}{end}'
+
+  # Validate the dependencies of all kustomizations, e.g. in a CI pipeline
+  flux get kustomizations --depends-graph-check --no-cross-namespace-refs
 `,
 	RunE: getKsCmdRun,
 }
 
 func init() {
+	addDependsCheckFlags(getKsCmd, "Kustomizations")
 	getCmd.AddCommand(getKsCmd)
 }
 
 func getKsCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
 
 	var listOpts []client.ListOption
-	if !getArgs.allNamespaces {
+	if !getArgs.allNamespaces && !dependsCheckArgs.enabled {
 		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
 	}
 	var list kustomizev1.KustomizationList
-	err = kubeClient.List(ctx, &list, listOpts...)
+	err = listGetObjects(ctx, kubeClient, &list, listOpts...)
 	if err != nil {
 		return err
 	}
 
-	if len(list.Items) == 0 {
-		logger.Failuref("no kustomizations found in %s namespace", rootArgs.namespace)
-		return nil
+	if dependsCheckArgs.enabled {
+		var items []dependent
+		for _, item := range list.Items {
+			items = append(items, dependent{
+				namespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name},
+				dependsOn:      item.Spec.DependsOn,
+			})
+		}
+		return checkDependencyGraph("Kustomizations", items)
+	}
+
+	if !getTableOutput() {
+		return utils.PrintTemplate(os.Stdout, getArgs.output.Format, getArgs.output.Template, &list)
 	}
 
-	header := []string{"Name", "Ready", "Message", "Revision", "Suspended"}
+	header := []string{"Name", "Ready", "Message", "Revision", "Suspended", "Prune"}
 	if getArgs.allNamespaces {
 		header = append([]string{"Namespace"}, header...)
 	}
@@ -85,6 +103,7 @@ func getKsCmdRun(cmd *cobra.Command, args []string) error {
 				c.Message,
 				kustomization.Status.LastAppliedRevision,
 				strings.Title(strconv.FormatBool(kustomization.Spec.Suspend)),
+				strings.Title(strconv.FormatBool(kustomization.Spec.Prune)),
 			}
 		} else {
 			row = []string{
@@ -93,6 +112,7 @@ func getKsCmdRun(cmd *cobra.Command, args []string) error {
 				"waiting to be reconciled",
 				kustomization.Status.LastAppliedRevision,
 				strings.Title(strconv.FormatBool(kustomization.Spec.Suspend)),
+				strings.Title(strconv.FormatBool(kustomization.Spec.Prune)),
 			}
 		}
 		if getArgs.allNamespaces {
@@ -100,6 +120,5 @@ func getKsCmdRun(cmd *cobra.Command, args []string) error {
 		}
 		rows = append(rows, row)
 	}
-	utils.PrintTable(os.Stdout, header, rows)
-	return nil
+	return printGetRows("kustomizations", header, rows)
 }
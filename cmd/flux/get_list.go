@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// listGetObjects fills list with the objects matching the options, using
+// paginated List calls of --chunk-size objects. When the options don't
+// restrict the namespace and --max-concurrency is set, the namespaces are
+// listed in parallel with at most --max-concurrency requests in flight,
+// and the items are merged in the namespaces order.
+func listGetObjects(ctx context.Context, kubeClient client.Client, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.Namespace != "" || getArgs.maxConcurrency <= 0 {
+		return listPages(ctx, kubeClient, list, opts...)
+	}
+
+	var namespaces corev1.NamespaceList
+	if err := listPages(ctx, kubeClient, &namespaces); err != nil {
+		if apierrors.IsForbidden(err) {
+			// fallback to a cluster wide list for users which can't
+			// list namespaces but can list the objects
+			return listPages(ctx, kubeClient, list, opts...)
+		}
+		return err
+	}
+
+	items := make([][]runtime.Object, len(namespaces.Items))
+	errs := make([]error, len(namespaces.Items))
+	sem := make(chan struct{}, getArgs.maxConcurrency)
+	var wg sync.WaitGroup
+	for i, ns := range namespaces.Items {
+		wg.Add(1)
+		go func(i int, namespace string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			nsList := list.DeepCopyObject().(client.ObjectList)
+			nsOpts := append([]client.ListOption{client.InNamespace(namespace)}, opts...)
+			if err := listPages(ctx, kubeClient, nsList, nsOpts...); err != nil {
+				errs[i] = fmt.Errorf("listing objects in %s namespace failed: %w", namespace, err)
+				return
+			}
+			items[i], errs[i] = apimeta.ExtractList(nsList)
+		}(i, ns.Name)
+	}
+	wg.Wait()
+
+	var all []runtime.Object
+	for i := range items {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		all = append(all, items[i]...)
+	}
+	// the lists of the namespaces have their own resource versions, the
+	// merged list has none
+	list.SetResourceVersion("")
+	return apimeta.SetList(list, all)
+}
+
+// listPages fills list with the items of successive List calls of
+// --chunk-size objects, following the continue token of each page.
+func listPages(ctx context.Context, kubeClient client.Client, list client.ObjectList, opts ...client.ListOption) error {
+	if getArgs.chunkSize <= 0 {
+		return kubeClient.List(ctx, list, opts...)
+	}
+
+	var all []runtime.Object
+	token := ""
+	for {
+		page := list.DeepCopyObject().(client.ObjectList)
+		pageOpts := append([]client.ListOption{client.Limit(getArgs.chunkSize), client.Continue(token)}, opts...)
+		if err := kubeClient.List(ctx, page, pageOpts...); err != nil {
+			return err
+		}
+		items, err := apimeta.ExtractList(page)
+		if err != nil {
+			return err
+		}
+		all = append(all, items...)
+
+		token = page.GetContinue()
+		if token == "" {
+			if err := apimeta.SetList(list, all); err != nil {
+				return err
+			}
+			list.SetResourceVersion(page.GetResourceVersion())
+			return nil
+		}
+	}
+}
@@ -47,10 +47,10 @@ func init() {
 }
 
 func getReceiverCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -60,17 +60,16 @@ func getReceiverCmdRun(cmd *cobra.Command, args []string) error {
 		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
 	}
 	var list notificationv1.ReceiverList
-	err = kubeClient.List(ctx, &list, listOpts...)
+	err = listGetObjects(ctx, kubeClient, &list, listOpts...)
 	if err != nil {
 		return err
 	}
 
-	if len(list.Items) == 0 {
-		logger.Failuref("no receivers found in %s namespace", rootArgs.namespace)
-		return nil
+	if !getTableOutput() {
+		return utils.PrintTemplate(os.Stdout, getArgs.output.Format, getArgs.output.Template, &list)
 	}
 
-	header := []string{"Name", "Ready", "Message", "Suspended"}
+	header := []string{"Name", "Ready", "Message", "Suspended", "Secret"}
 	if getArgs.allNamespaces {
 		header = append([]string{"Namespace"}, header...)
 	}
@@ -92,8 +91,11 @@ func getReceiverCmdRun(cmd *cobra.Command, args []string) error {
 				strings.Title(strconv.FormatBool(receiver.Spec.Suspend)),
 			}
 		}
+		row = append(row, secretStatus(ctx, kubeClient, receiver.Namespace, receiver.Spec.SecretRef.Name, "token"))
+		if getArgs.allNamespaces {
+			row = append([]string{receiver.Namespace}, row...)
+		}
 		rows = append(rows, row)
 	}
-	utils.PrintTable(os.Stdout, header, rows)
-	return nil
+	return printGetRows("receivers", header, rows)
 }
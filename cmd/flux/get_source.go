@@ -17,7 +17,17 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/spf13/cobra"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 )
 
 var getSourceCmd = &cobra.Command{
@@ -26,6 +36,102 @@ var getSourceCmd = &cobra.Command{
 	Long:  "The get source sub-commands print the statuses of the sources.",
 }
 
+type getSourceFlags struct {
+	stale          bool
+	maxAge         time.Duration
+	staleIntervals int
+	failOnStale    bool
+}
+
+var getSourceArgs = getSourceFlags{
+	staleIntervals: 3,
+}
+
 func init() {
+	getSourceCmd.PersistentFlags().BoolVar(&getSourceArgs.stale, "stale", false,
+		"only list the sources with a stale artifact")
+	getSourceCmd.PersistentFlags().DurationVar(&getSourceArgs.maxAge, "max-age", 0,
+		"the age after which an artifact is stale, when not specified the source interval multiplied by --stale-intervals is used")
+	getSourceCmd.PersistentFlags().IntVar(&getSourceArgs.staleIntervals, "stale-intervals", getSourceArgs.staleIntervals,
+		"the number of missed intervals after which an artifact is stale")
+	getSourceCmd.PersistentFlags().BoolVar(&getSourceArgs.failOnStale, "fail-on-stale", false,
+		"exit with a non-zero status if a source has a stale artifact")
 	getCmd.AddCommand(getSourceCmd)
 }
+
+// staleSource is implemented by all the source kinds.
+type staleSource interface {
+	sourcev1.Source
+	GetCreationTimestamp() metav1.Time
+}
+
+// showArtifactAge returns true if the staleness of the artifacts has been
+// asked for, in which case their age is listed.
+func (f getSourceFlags) showArtifactAge() bool {
+	return f.stale || f.maxAge > 0 || f.failOnStale
+}
+
+// artifactAge returns the time elapsed since the artifact was last updated,
+// or since the source was created if it has no artifact yet.
+func artifactAge(source staleSource, now time.Time) time.Duration {
+	if artifact := source.GetArtifact(); artifact != nil {
+		return now.Sub(artifact.LastUpdateTime.Time)
+	}
+	return now.Sub(source.GetCreationTimestamp().Time)
+}
+
+// isStaleSource returns true if the artifact is older than --max-age or
+// than the given number of source intervals.
+func isStaleSource(source staleSource, now time.Time) bool {
+	maxAge := getSourceArgs.maxAge
+	if maxAge == 0 {
+		maxAge = time.Duration(getSourceArgs.staleIntervals) * source.GetInterval().Duration
+	}
+	return artifactAge(source, now) > maxAge
+}
+
+// filterStaleSources counts the sources of the list with a stale artifact
+// and, with --stale, removes the other sources from the list.
+func filterStaleSources(list client.ObjectList, now time.Time) (int, error) {
+	objects, err := apimeta.ExtractList(list)
+	if err != nil {
+		return 0, err
+	}
+	var stale int
+	var items []runtime.Object
+	for _, obj := range objects {
+		source, ok := obj.(staleSource)
+		if !ok {
+			return 0, fmt.Errorf("%T is not a source", obj)
+		}
+		isStale := isStaleSource(source, now)
+		if isStale {
+			stale++
+		}
+		if !getSourceArgs.stale || isStale {
+			items = append(items, obj)
+		}
+	}
+	return stale, apimeta.SetList(list, items)
+}
+
+// artifactAgeColumn formats the artifact age for the table output.
+func artifactAgeColumn(source staleSource, now time.Time) string {
+	if source.GetArtifact() == nil {
+		return "-"
+	}
+	age := duration.HumanDuration(artifactAge(source, now))
+	if isStaleSource(source, now) {
+		return age + " (stale)"
+	}
+	return age
+}
+
+// staleSourcesError returns an error with --fail-on-stale if stale
+// artifacts were found.
+func staleSourcesError(kind string, count int) error {
+	if getSourceArgs.failOnStale && count > 0 {
+		return fmt.Errorf("found %d %s with a stale artifact", count, kind)
+	}
+	return nil
+}
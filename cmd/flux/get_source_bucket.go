@@ -18,17 +18,26 @@ package main
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/pkg/apis/meta"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -41,19 +50,30 @@ var getSourceBucketCmd = &cobra.Command{
 
  # List buckets from all namespaces
   flux get sources helm --all-namespaces
+
+  # List all Buckets and probe their endpoints from this machine
+  flux get sources bucket --show-endpoint-health
 `,
 	RunE: getSourceBucketCmdRun,
 }
 
+type getSourceBucketFlags struct {
+	showEndpointHealth bool
+}
+
+var gsbArgs getSourceBucketFlags
+
 func init() {
+	getSourceBucketCmd.Flags().BoolVar(&gsbArgs.showEndpointHealth, "show-endpoint-health", false,
+		"probe the bucket endpoints from this machine using the credentials of the bucket secret, requires permission to read secrets")
 	getSourceCmd.AddCommand(getSourceBucketCmd)
 }
 
 func getSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -63,17 +83,31 @@ func getSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
 		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
 	}
 	var list sourcev1.BucketList
-	err = kubeClient.List(ctx, &list, listOpts...)
+	err = listGetObjects(ctx, kubeClient, &list, listOpts...)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	stale, err := filterStaleSources(&list, now)
 	if err != nil {
 		return err
 	}
 
-	if len(list.Items) == 0 {
-		logger.Failuref("no bucket sources found in %s namespace", rootArgs.namespace)
-		return nil
+	if !getTableOutput() {
+		if err := utils.PrintTemplate(os.Stdout, getArgs.output.Format, getArgs.output.Template, &list); err != nil {
+			return err
+		}
+		return staleSourcesError("bucket sources", stale)
 	}
 
 	header := []string{"Name", "Ready", "Message", "Revision", "Suspended"}
+	if gsbArgs.showEndpointHealth {
+		header = append(header, "Endpoint")
+	}
+	if getSourceArgs.showArtifactAge() {
+		header = append(header, "Artifact Age")
+	}
 	if getArgs.allNamespaces {
 		header = append([]string{"Namespace"}, header...)
 	}
@@ -101,11 +135,134 @@ func getSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
 				strings.Title(strconv.FormatBool(source.Spec.Suspend)),
 			}
 		}
+		if gsbArgs.showEndpointHealth {
+			row = append(row, probeBucketEndpoint(ctx, kubeClient, source))
+		}
+		if getSourceArgs.showArtifactAge() {
+			row = append(row, artifactAgeColumn(&source, now))
+		}
 		if getArgs.allNamespaces {
 			row = append([]string{source.Namespace}, row...)
 		}
 		rows = append(rows, row)
 	}
-	utils.PrintTable(os.Stdout, header, rows)
-	return nil
+	if err := printGetRows("bucket sources", header, rows); err != nil {
+		return err
+	}
+	return staleSourcesError("bucket sources", stale)
+}
+
+// probeBucketEndpoint sends a signed HEAD request for the bucket from the
+// CLI machine, so that an endpoint which can't be reached is told apart
+// from credentials which are rejected.
+func probeBucketEndpoint(ctx context.Context, kubeClient client.Client, bucket sourcev1.Bucket) string {
+	status, err := requestBucketEndpoint(ctx, kubeClient, bucket)
+	if err != nil {
+		return fmt.Sprintf("%s: %v", status, err)
+	}
+	return status
+}
+
+// requestBucketEndpoint returns the endpoint status of the bucket along
+// with the error which caused the request to fail, if any.
+func requestBucketEndpoint(ctx context.Context, kubeClient client.Client, bucket sourcev1.Bucket) (string, error) {
+	var accessKey, secretKey string
+	if bucket.Spec.SecretRef != nil {
+		var secret corev1.Secret
+		namespacedName := types.NamespacedName{
+			Namespace: bucket.Namespace,
+			Name:      bucket.Spec.SecretRef.Name,
+		}
+		if err := kubeClient.Get(ctx, namespacedName, &secret); err != nil {
+			switch {
+			case apierrors.IsNotFound(err):
+				return "SecretNotFound", nil
+			case apierrors.IsForbidden(err):
+				return "SecretForbidden", nil
+			}
+			return "SecretError", err
+		}
+		accessKey = string(secret.Data["accesskey"])
+		secretKey = string(secret.Data["secretkey"])
+	}
+
+	scheme := "https"
+	if bucket.Spec.Insecure {
+		scheme = "http"
+	}
+	url := fmt.Sprintf("%s://%s/%s", scheme, bucket.Spec.Endpoint, bucket.Spec.BucketName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return "InvalidEndpoint", err
+	}
+	if accessKey != "" && secretKey != "" {
+		region := bucket.Spec.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		signS3Request(req, accessKey, secretKey, region, time.Now().UTC())
+	}
+
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "Unreachable", err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return "Healthy", nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "CredentialsRejected", nil
+	case resp.StatusCode == http.StatusNotFound:
+		return "BucketNotFound", nil
+	default:
+		return "Unhealthy", fmt.Errorf(resp.Status)
+	}
+}
+
+// signS3Request adds the AWS Signature Version 4 headers to a request
+// without a body, as accepted by S3 compatible endpoints.
+func signS3Request(req *http.Request, accessKey, secretKey, region string, now time.Time) {
+	const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("x-amz-content-sha256", emptyPayloadHash)
+	req.Header.Set("x-amz-date", amzDate)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, emptyPayloadHash, amzDate),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, region)
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
 }
@@ -21,6 +21,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/pkg/apis/meta"
@@ -50,10 +51,10 @@ func init() {
 }
 
 func getSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -63,17 +64,28 @@ func getSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
 		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
 	}
 	var list sourcev1.HelmRepositoryList
-	err = kubeClient.List(ctx, &list, listOpts...)
+	err = listGetObjects(ctx, kubeClient, &list, listOpts...)
 	if err != nil {
 		return err
 	}
 
-	if len(list.Items) == 0 {
-		logger.Failuref("no helm sources found in %s namespace", rootArgs.namespace)
-		return nil
+	now := time.Now()
+	stale, err := filterStaleSources(&list, now)
+	if err != nil {
+		return err
+	}
+
+	if !getTableOutput() {
+		if err := utils.PrintTemplate(os.Stdout, getArgs.output.Format, getArgs.output.Template, &list); err != nil {
+			return err
+		}
+		return staleSourcesError("helm sources", stale)
 	}
 
 	header := []string{"Name", "Ready", "Message", "Revision", "Suspended"}
+	if getSourceArgs.showArtifactAge() {
+		header = append(header, "Artifact Age")
+	}
 	if getArgs.allNamespaces {
 		header = append([]string{"Namespace"}, header...)
 	}
@@ -101,11 +113,16 @@ func getSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
 				strings.Title(strconv.FormatBool(source.Spec.Suspend)),
 			}
 		}
+		if getSourceArgs.showArtifactAge() {
+			row = append(row, artifactAgeColumn(&source, now))
+		}
 		if getArgs.allNamespaces {
 			row = append([]string{source.Namespace}, row...)
 		}
 		rows = append(rows, row)
 	}
-	utils.PrintTable(os.Stdout, header, rows)
-	return nil
+	if err := printGetRows("helm sources", header, rows); err != nil {
+		return err
+	}
+	return staleSourcesError("helm sources", stale)
 }
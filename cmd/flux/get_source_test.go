@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+func TestFilterStaleSources(t *testing.T) {
+	now := time.Now()
+	source := func(name string, updated time.Duration) sourcev1.GitRepository {
+		return sourcev1.GitRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       sourcev1.GitRepositorySpec{Interval: metav1.Duration{Duration: time.Minute}},
+			Status: sourcev1.GitRepositoryStatus{
+				Artifact: &sourcev1.Artifact{LastUpdateTime: metav1.NewTime(now.Add(-updated))},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		stale      bool
+		wantStale  int
+		wantSource []string
+	}{
+		{
+			name:       "all sources",
+			wantStale:  1,
+			wantSource: []string{"fresh", "stale"},
+		},
+		{
+			name:       "only stale sources",
+			stale:      true,
+			wantStale:  1,
+			wantSource: []string{"stale"},
+		},
+	}
+	defer func(args getSourceFlags) { getSourceArgs = args }(getSourceArgs)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getSourceArgs = getSourceFlags{stale: tt.stale, staleIntervals: 3}
+			list := sourcev1.GitRepositoryList{Items: []sourcev1.GitRepository{
+				source("fresh", time.Minute),
+				source("stale", time.Hour),
+			}}
+			stale, err := filterStaleSources(&list, now)
+			if err != nil {
+				t.Fatalf("filterStaleSources() error = %v", err)
+			}
+			if stale != tt.wantStale {
+				t.Errorf("stale = %d, want %d", stale, tt.wantStale)
+			}
+			var names []string
+			for _, item := range list.Items {
+				names = append(names, item.Name)
+			}
+			if len(names) != len(tt.wantSource) {
+				t.Fatalf("sources = %v, want %v", names, tt.wantSource)
+			}
+			for i := range names {
+				if names[i] != tt.wantSource[i] {
+					t.Errorf("sources = %v, want %v", names, tt.wantSource)
+				}
+			}
+		})
+	}
+}
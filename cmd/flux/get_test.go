@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+func TestValidateGetFlags(t *testing.T) {
+	template := flags.Output{Format: utils.OutputGoTemplate, Template: "{{.}}"}
+	tests := []struct {
+		name    string
+		args    GetFlags
+		wantErr bool
+	}{
+		{
+			name: "table with status selector",
+			args: GetFlags{output: flags.Output{Format: utils.OutputTable}, statusSelector: []string{"ready=false"}, exitCodeOnEmpty: true},
+		},
+		{
+			name: "template",
+			args: GetFlags{output: template},
+		},
+		{
+			name:    "template with status selector",
+			args:    GetFlags{output: template, statusSelector: []string{"ready=false"}},
+			wantErr: true,
+		},
+		{
+			name:    "template with exit code on empty",
+			args:    GetFlags{output: template, exitCodeOnEmpty: true},
+			wantErr: true,
+		},
+		{
+			name: "count with status selector",
+			args: GetFlags{output: template, count: true, statusSelector: []string{"ready=false"}, exitCodeOnEmpty: true},
+		},
+	}
+	defer func(args GetFlags) { getArgs = args }(getArgs)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getArgs = tt.args
+			if err := validateGetFlags(getCmd, nil); (err != nil) != tt.wantErr {
+				t.Errorf("validateGetFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// countingClient counts the List calls per namespace, "" for the cluster
+// wide calls.
+type countingClient struct {
+	client.Client
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if _, ok := list.(*corev1.ConfigMapList); ok {
+		c.mu.Lock()
+		c.calls[listOpts.Namespace]++
+		c.mu.Unlock()
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestListGetObjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	var objs []runtime.Object
+	for _, ns := range []string{"apps", "flux-system"} {
+		objs = append(objs,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: ns}})
+	}
+
+	tests := []struct {
+		name           string
+		maxConcurrency int
+		expectCalls    map[string]int
+	}{
+		{"cluster-wide by default", 0, map[string]int{"": 1}},
+		{"per namespace on request", 2, map[string]int{"apps": 1, "flux-system": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(maxConcurrency int) { getArgs.maxConcurrency = maxConcurrency }(getArgs.maxConcurrency)
+			getArgs.maxConcurrency = tt.maxConcurrency
+
+			kubeClient := &countingClient{
+				Client: fake.NewFakeClientWithScheme(scheme, objs...),
+				calls:  map[string]int{},
+			}
+			var list corev1.ConfigMapList
+			if err := listGetObjects(context.TODO(), kubeClient, &list); err != nil {
+				t.Fatal(err)
+			}
+			if len(list.Items) != 2 {
+				t.Errorf("items = %d, expect 2", len(list.Items))
+			}
+			if len(kubeClient.calls) != len(tt.expectCalls) {
+				t.Errorf("calls = %v, expect %v", kubeClient.calls, tt.expectCalls)
+			}
+			for ns, n := range tt.expectCalls {
+				if kubeClient.calls[ns] != n {
+					t.Errorf("calls = %v, expect %v", kubeClient.calls, tt.expectCalls)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/pkg/hooks"
+)
+
+const hooksTimeout = 10 * time.Second
+
+// notifyTarget is set by the --notify flag of the long-running commands.
+var notifyTarget flags.NotifyTarget
+
+func addNotifyFlag(fs *pflag.FlagSet) {
+	fs.Var(&notifyTarget, "notify", notifyTarget.Description())
+}
+
+// notifyHook returns the hook sending the completion message to the
+// --notify target, if any.
+func notifyHook() []hooks.Hook {
+	switch notifyTarget {
+	case "":
+		return nil
+	case flags.NotifyDesktop:
+		return []hooks.Hook{hooks.DesktopHook{}}
+	default:
+		return []hooks.Hook{hooks.WebhookHook{URL: notifyTarget.String()}}
+	}
+}
+
+// runCommandHooks notifies the hooks registered with hooks.Register and
+// FLUX_CLI_HOOKS of the command outcome, a failing hook is reported but
+// doesn't change the command result.
+func runCommandHooks(start time.Time, cmdErr error) {
+	registered := append(hooks.Registered(), hooks.FromEnv()...)
+	registered = append(registered, notifyHook()...)
+	if len(registered) == 0 {
+		return
+	}
+
+	cmd, _, err := rootCmd.Find(os.Args[1:])
+	if err != nil {
+		return
+	}
+	event := hooks.Event{
+		Command:  cmd.CommandPath(),
+		Duration: time.Since(start),
+		Error:    cmdErr,
+	}
+	if args := cmd.Flags().Args(); len(args) > 0 {
+		event.Resource = fmt.Sprintf("%s/%s/%s", cmd.Name(), rootArgs.namespace, args[0])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hooksTimeout)
+	defer cancel()
+	for _, hook := range registered {
+		if err := hook.CommandCompleted(ctx, event); err != nil {
+			logger.Failuref("%v", err)
+		}
+	}
+}
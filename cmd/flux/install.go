@@ -18,6 +18,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -39,14 +40,29 @@ If a previous version is installed, then an in-place upgrade will be performed.`
 	Example: `  # Install the latest version in the flux-system namespace
   flux install --version=latest --namespace=flux-system
 
+  # Install the latest patch release of a minor version
+  flux install --version=v0.7.x
+
   # Dry-run install for a specific version and a series of components
   flux install --dry-run --version=v0.0.7 --components="source-controller,kustomize-controller"
 
   # Dry-run install with manifests preview
   flux install --dry-run --verbose
 
+  # Upgrade only the components that changed and wait for their rollout
+  flux install --upgrade --version=latest
+
   # Write install manifests to file
   flux install --export > flux-system.yaml
+
+  # List the container images and digests of the components, e.g. to mirror them before an air-gapped install
+  flux install --list-images --components-extra=image-reflector-controller,image-automation-controller
+
+  # Write an SPDX document of the container images
+  flux install --list-images=spdx > flux-images.spdx.json
+
+  # Write the install options as values for the community Helm chart
+  flux install --export --as-helm-values --components-extra=image-reflector-controller > values.yaml
 `,
 	RunE: installCmdRun,
 }
@@ -65,6 +81,12 @@ var (
 	installArch               flags.Arch
 	installLogLevel           = flags.LogLevel(rootArgs.defaults.LogLevel)
 	installClusterDomain      string
+	installAsHelmValues       bool
+	installAsTerraform        bool
+	installListImages         flags.ImageListFormat
+	installStatusFile         string
+	installUpgrade            bool
+	installWait               bool
 )
 
 func init() {
@@ -73,7 +95,7 @@ func init() {
 	installCmd.Flags().BoolVarP(&installDryRun, "dry-run", "", false,
 		"only print the object that would be applied")
 	installCmd.Flags().StringVarP(&installVersion, "version", "v", rootArgs.defaults.Version,
-		"toolkit version")
+		"toolkit version, can be 'latest', a release e.g. v0.7.1 or a minor release series e.g. v0.7.x for its latest patch")
 	installCmd.Flags().StringSliceVar(&installDefaultComponents, "components", rootArgs.defaults.Components,
 		"list of components, accepts comma-separated values")
 	installCmd.Flags().StringSliceVar(&installExtraComponents, "components-extra", nil,
@@ -90,22 +112,43 @@ func init() {
 	installCmd.Flags().BoolVar(&installNetworkPolicy, "network-policy", rootArgs.defaults.NetworkPolicy,
 		"deny ingress access to the toolkit controllers from other namespaces using network policies")
 	installCmd.Flags().StringVar(&installClusterDomain, "cluster-domain", rootArgs.defaults.ClusterDomain, "internal cluster domain")
+	installCmd.Flags().BoolVar(&installAsHelmValues, "as-helm-values", false,
+		"used with --export, write the install options as values for the community Helm chart version which deploys --version instead of manifests")
+	installCmd.Flags().BoolVar(&installAsTerraform, "as-terraform", false,
+		"used with --export, write the install options as a flux_install Terraform data source instead of manifests")
+	installCmd.Flags().Var(&installListImages, "list-images", installListImages.Description())
+	installCmd.Flags().Lookup("list-images").NoOptDefVal = "text"
+	addStatusFileFlag(installCmd.Flags(), &installStatusFile)
+	installCmd.Flags().BoolVar(&installUpgrade, "upgrade", false,
+		"compare the components with the cluster state and only apply the objects that changed")
+	installCmd.Flags().BoolVar(&installWait, "wait", true,
+		"wait for the rollout of the components, with --upgrade only the restarted ones are waited for")
 	installCmd.Flags().MarkHidden("manifests")
 	installCmd.Flags().MarkDeprecated("arch", "multi-arch container image is now available for AMD64, ARMv7 and ARM64")
 	rootCmd.AddCommand(installCmd)
 }
 
-func installCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+func installCmdRun(cmd *cobra.Command, args []string) (retErr error) {
+	status := newStatusFile(installStatusFile, cmd.CommandPath())
+	defer func() { status.finish(retErr) }()
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
+	if (installAsHelmValues || installAsTerraform) && !installExport {
+		return fmt.Errorf("--as-helm-values and --as-terraform can only be used with --export")
+	}
+	if installAsHelmValues && installAsTerraform {
+		return fmt.Errorf("--as-helm-values and --as-terraform are mutually exclusive")
+	}
+
 	tmpDir, err := ioutil.TempDir("", rootArgs.namespace)
 	if err != nil {
 		return err
 	}
 	defer os.RemoveAll(tmpDir)
 
-	if !installExport {
+	if !installExport && installListImages == "" {
 		logger.Generatef("generating manifests")
 	}
 
@@ -133,6 +176,36 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 
 	if installManifestsPath == "" {
 		opts.BaseURL = install.MakeDefaultOptions().BaseURL
+		opts.CacheDir = manifestsCacheDir()
+
+		version, err := install.ResolveVersion(ctx, opts.BaseURL, opts.Version)
+		if err != nil {
+			return fmt.Errorf("install failed: %w", err)
+		}
+		opts.Version = version
+	}
+
+	if installAsHelmValues {
+		if !install.IsExactVersion(opts.Version) {
+			return fmt.Errorf("--as-helm-values requires a released version, got %s", opts.Version)
+		}
+		chart, err := install.FetchHelmChart(ctx, install.HelmChartIndexURL, opts.Version)
+		if err != nil {
+			return fmt.Errorf("install failed: %w", err)
+		}
+		values, err := install.HelmValues(opts, chart)
+		if err != nil {
+			return fmt.Errorf("install failed: %w", err)
+		}
+		fmt.Println("# GitOps Toolkit revision", opts.Version)
+		fmt.Println("# flux2 chart version", chart.Version)
+		fmt.Print(values)
+		return nil
+	}
+
+	if installAsTerraform {
+		fmt.Print(install.TerraformInstall(opts))
+		return nil
 	}
 
 	manifest, err := install.Generate(opts)
@@ -140,6 +213,10 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("install failed: %w", err)
 	}
 
+	if installListImages != "" {
+		return listInstallImages(ctx, manifest.Content, opts.Version, installListImages.String())
+	}
+
 	if _, err := manifest.WriteFile(tmpDir); err != nil {
 		return fmt.Errorf("install failed: %w", err)
 	}
@@ -148,7 +225,7 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		fmt.Print(manifest.Content)
 	} else if installExport {
 		fmt.Println("---")
-		fmt.Println("# GitOps Toolkit revision", installVersion)
+		fmt.Println("# GitOps Toolkit revision", opts.Version)
 		fmt.Println("# Components:", strings.Join(components, ","))
 		fmt.Print(manifest.Content)
 		fmt.Println("---")
@@ -156,19 +233,47 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	logger.Successf("manifests build completed")
+	status.phase(phaseManifestsGenerated, "")
+
+	manifestPath := filepath.Join(tmpDir, manifest.Path)
+	deployments := components
+	if installUpgrade {
+		logger.Actionf("comparing components with the cluster state")
+		changed, err := changedInstallObjects(ctx, manifest.Content, manifestPath)
+		if err != nil {
+			return fmt.Errorf("install failed: %w", err)
+		}
+		if len(changed) == 0 {
+			logger.Successf("components are up to date")
+			return nil
+		}
+		for _, obj := range changed {
+			logger.Generatef("%s/%s changed", obj.GetKind(), obj.GetName())
+		}
+		if manifestPath, err = writeObjects(tmpDir, changed); err != nil {
+			return fmt.Errorf("install failed: %w", err)
+		}
+		deployments = changedDeployments(changed, components)
+	}
+
 	logger.Actionf("installing components in %s namespace", rootArgs.namespace)
 	applyOutput := utils.ModeStderrOS
 	if rootArgs.verbose {
 		applyOutput = utils.ModeOS
 	}
 
-	kubectlArgs := []string{"apply", "-f", filepath.Join(tmpDir, manifest.Path)}
+	kubectlArgs := utils.KubectlApplyArgs("-f", manifestPath)
 	if installDryRun {
-		kubectlArgs = append(kubectlArgs, "--dry-run=client")
+		// server-side apply can't be combined with a client dry-run
+		if rootArgs.forceConflicts {
+			kubectlArgs = append(kubectlArgs, "--dry-run=server")
+		} else {
+			kubectlArgs = append(kubectlArgs, "--dry-run=client")
+		}
 		applyOutput = utils.ModeOS
 	}
-	if _, err := utils.ExecKubectlCommand(ctx, applyOutput, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err != nil {
-		return fmt.Errorf("install failed")
+	if _, err := utils.ExecKubectlApply(ctx, applyOutput, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err != nil {
+		return installError(err)
 	}
 
 	if installDryRun {
@@ -176,10 +281,16 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		return nil
 	} else {
 		logger.Successf("install completed")
+		status.phase(phaseComponentsApplied, "")
+	}
+
+	if !installWait {
+		logger.Successf("install finished")
+		return nil
 	}
 
 	logger.Waitingf("verifying installation")
-	for _, deployment := range components {
+	for _, deployment := range deployments {
 		kubectlArgs = []string{"-n", rootArgs.namespace, "rollout", "status", "deployment", deployment, "--timeout", rootArgs.timeout.String()}
 		if _, err := utils.ExecKubectlCommand(ctx, applyOutput, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err != nil {
 			return fmt.Errorf("install failed")
@@ -188,6 +299,18 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	status.phase(phaseComponentsHealthy, "")
 	logger.Successf("install finished")
 	return nil
 }
+
+// installError returns the error of a failed kubectl apply. As kubectl
+// already printed its output, only the admission webhook denials are
+// summarised.
+func installError(err error) error {
+	var denied *utils.WebhookDenialError
+	if errors.As(err, &denied) {
+		return fmt.Errorf("install failed: %w", err)
+	}
+	return fmt.Errorf("install failed")
+}
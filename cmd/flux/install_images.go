@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+)
+
+type installImage struct {
+	Image  string `json:"image"`
+	Digest string `json:"digest"`
+}
+
+// listInstallImages prints the container images of the install manifests
+// along with the digests their tags resolve to in the registry.
+func listInstallImages(ctx context.Context, manifests, version, format string) error {
+	refs, err := install.Images(manifests)
+	if err != nil {
+		return err
+	}
+
+	// the registries are reached through the proxy of the environment,
+	// the --proxy flag belongs to the artifact commands
+	var images []installImage
+	for _, image := range refs {
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			return fmt.Errorf("invalid image reference %s: %w", image, err)
+		}
+		desc, err := remote.Head(ref, remote.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("unable to resolve the digest of %s: %w", image, err)
+		}
+		images = append(images, installImage{Image: image, Digest: desc.Digest.String()})
+	}
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(images, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "spdx":
+		doc, err := spdxDocument(images, version)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		var rows [][]string
+		for _, image := range images {
+			rows = append(rows, []string{image.Image, image.Digest})
+		}
+		utils.PrintTable(os.Stdout, []string{"Image", "Digest"}, rows)
+	}
+	return nil
+}
+
+// spdxDocument returns an SPDX 2.2 document listing the images as packages,
+// the digest of each image is part of its package URL.
+func spdxDocument(images []installImage, version string) (map[string]interface{}, error) {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	var packages []map[string]interface{}
+	for i, image := range images {
+		ref, err := name.ParseReference(image.Image)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image reference %s: %w", image.Image, err)
+		}
+		repository := ref.Context().RepositoryStr()
+		purl := fmt.Sprintf("pkg:oci/%s@%s?repository_url=%s/%s",
+			repository[strings.LastIndex(repository, "/")+1:], image.Digest, ref.Context().RegistryStr(), repository)
+		if tag, ok := ref.(name.Tag); ok {
+			purl += "&tag=" + tag.TagStr()
+		}
+		packages = append(packages, map[string]interface{}{
+			"name":             image.Image,
+			"SPDXID":           fmt.Sprintf("SPDXRef-Package-%d", i),
+			"versionInfo":      ref.Identifier(),
+			"downloadLocation": "NOASSERTION",
+			"filesAnalyzed":    false,
+			"externalRefs": []map[string]string{{
+				"referenceCategory": "PACKAGE-MANAGER",
+				"referenceType":     "purl",
+				"referenceLocator":  purl,
+			}},
+		})
+	}
+
+	return map[string]interface{}{
+		"spdxVersion":       "SPDX-2.2",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              fmt.Sprintf("flux-%s", version),
+		"documentNamespace": fmt.Sprintf("https://fluxcd.io/spdx/flux-%s-%s", version, hex.EncodeToString(id)),
+		"creationInfo": map[string]interface{}{
+			"created":  time.Now().UTC().Format(time.RFC3339),
+			"creators": []string{fmt.Sprintf("Tool: flux-%s", VERSION)},
+		},
+		"packages": packages,
+	}, nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestSpdxDocument(t *testing.T) {
+	digest := "sha256:0123"
+	tests := []struct {
+		image       string
+		versionInfo string
+		purl        string
+	}{
+		{"ghcr.io/fluxcd/source-controller:v0.7.0", "v0.7.0",
+			"pkg:oci/source-controller@sha256:0123?repository_url=ghcr.io/fluxcd/source-controller&tag=v0.7.0"},
+		{"localhost:5000/flux/source-controller", "latest",
+			"pkg:oci/source-controller@sha256:0123?repository_url=localhost:5000/flux/source-controller&tag=latest"},
+		{"fluxcd/flux-cli:v0.7.0", "v0.7.0",
+			"pkg:oci/flux-cli@sha256:0123?repository_url=index.docker.io/fluxcd/flux-cli&tag=v0.7.0"},
+		{"alpine:3.13", "3.13",
+			"pkg:oci/alpine@sha256:0123?repository_url=index.docker.io/library/alpine&tag=3.13"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			doc, err := spdxDocument([]installImage{{Image: tt.image, Digest: digest}}, "v0.7.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkg := doc["packages"].([]map[string]interface{})[0]
+			if pkg["versionInfo"] != tt.versionInfo {
+				t.Errorf("versionInfo = %v, want %v", pkg["versionInfo"], tt.versionInfo)
+			}
+			if _, ok := pkg["checksums"]; ok {
+				t.Errorf("unexpected checksums, the digest belongs to the purl")
+			}
+			if got := pkg["externalRefs"].([]map[string]string)[0]["referenceLocator"]; got != tt.purl {
+				t.Errorf("purl = %v, want %v", got, tt.purl)
+			}
+		})
+	}
+}
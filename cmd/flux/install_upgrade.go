@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+// changedInstallObjects compares the install manifests with the live
+// objects using kubectl diff, and returns the objects that would be
+// created or modified by an apply.
+func changedInstallObjects(ctx context.Context, manifests, manifestPath string) ([]unstructured.Unstructured, error) {
+	kubectlArgs := utils.KubectlDiffArgs("-f", manifestPath)
+	diff, err := utils.ExecKubectlDiff(ctx, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("diff failed: %w", err)
+	}
+
+	changed := map[string]bool{}
+	for _, line := range strings.Split(diff, "\n") {
+		// diff -u -N /tmp/LIVE-1/apps.v1.Deployment.flux-system.source-controller /tmp/MERGED-2/...
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == "diff" {
+			changed[filepath.Base(fields[len(fields)-1])] = true
+		}
+	}
+
+	var objects []unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(manifests), 2048)
+	for {
+		var obj unstructured.Unstructured
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode manifests: %w", err)
+		}
+		if obj.Object != nil && changed[kubectlDiffName(obj)] {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+// kubectlDiffName returns the name of the file in which kubectl diff
+// writes an object, in the format '[<group>.]<version>.<kind>.<namespace>.<name>'.
+func kubectlDiffName(obj unstructured.Unstructured) string {
+	gvk := obj.GroupVersionKind()
+	name := fmt.Sprintf("%s.%s.%s.%s", gvk.Version, gvk.Kind, obj.GetNamespace(), obj.GetName())
+	if gvk.Group != "" {
+		name = gvk.Group + "." + name
+	}
+	return name
+}
+
+// writeObjects writes the given objects to a multi-document YAML file in
+// dir and returns its path.
+func writeObjects(dir string, objects []unstructured.Unstructured) (string, error) {
+	var b strings.Builder
+	for _, obj := range objects {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("---\n")
+		b.Write(data)
+	}
+	path := filepath.Join(dir, "changed.yaml")
+	if err := ioutil.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// changedDeployments returns the components whose Deployment is among
+// the changed objects, as these are the ones restarted by the apply.
+func changedDeployments(objects []unstructured.Unstructured, components []string) []string {
+	var deployments []string
+	for _, component := range components {
+		for _, obj := range objects {
+			if obj.GetKind() == "Deployment" && obj.GetName() == component {
+				deployments = append(deployments, component)
+				break
+			}
+		}
+	}
+	return deployments
+}
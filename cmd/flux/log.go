@@ -19,28 +19,72 @@ package main
 import (
 	"fmt"
 	"io"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/log"
 )
 
+var _ log.ProgressReporter = stderrLogger{}
+
 type stderrLogger struct {
-	stderr io.Writer
+	stderr   io.Writer
+	colorize bool
+	// reporter receives the messages instead of stderr when set.
+	reporter log.ProgressReporter
+}
+
+func (l stderrLogger) symbol(color, symbol string) string {
+	if !l.colorize {
+		return symbol
+	}
+	return color + symbol + utils.ColorReset
 }
 
 func (l stderrLogger) Actionf(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `►`, fmt.Sprintf(format, a...))
+	if l.reporter != nil {
+		log.NewReporterLogger(l.reporter, nil).Actionf(format, a...)
+		return
+	}
+	fmt.Fprintln(l.stderr, l.symbol(utils.ColorBlue, `►`), fmt.Sprintf(format, a...))
 }
 
 func (l stderrLogger) Generatef(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `✚`, fmt.Sprintf(format, a...))
+	if l.reporter != nil {
+		log.NewReporterLogger(l.reporter, nil).Generatef(format, a...)
+		return
+	}
+	fmt.Fprintln(l.stderr, l.symbol(utils.ColorBlue, `✚`), fmt.Sprintf(format, a...))
 }
 
 func (l stderrLogger) Waitingf(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `◎`, fmt.Sprintf(format, a...))
+	if l.reporter != nil {
+		log.NewReporterLogger(l.reporter, nil).Waitingf(format, a...)
+		return
+	}
+	fmt.Fprintln(l.stderr, l.symbol(utils.ColorYellow, `◎`), fmt.Sprintf(format, a...))
 }
 
 func (l stderrLogger) Successf(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `✔`, fmt.Sprintf(format, a...))
+	if l.reporter != nil {
+		log.NewReporterLogger(l.reporter, nil).Successf(format, a...)
+		return
+	}
+	fmt.Fprintln(l.stderr, l.symbol(utils.ColorGreen, `✔`), fmt.Sprintf(format, a...))
 }
 
 func (l stderrLogger) Failuref(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `✗`, fmt.Sprintf(format, a...))
+	if l.reporter != nil {
+		log.NewReporterLogger(l.reporter, nil).Failuref(format, a...)
+		return
+	}
+	fmt.Fprintln(l.stderr, l.symbol(utils.ColorRed, `✗`), fmt.Sprintf(format, a...))
+}
+
+// Report prints the progress events of embedded operations.
+func (l stderrLogger) Report(event log.Event) {
+	if l.reporter != nil {
+		l.reporter.Report(event)
+		return
+	}
+	log.NewLoggerReporter(l).Report(event)
 }
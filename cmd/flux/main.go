@@ -17,15 +17,20 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
 )
 
@@ -42,7 +47,7 @@ var rootCmd = &cobra.Command{
   flux check --pre
 
   # Install the latest version of the toolkit
-  flux install --version=master
+  flux install --version=latest
 
   # Create a source from a public Git repository
   flux create source git webapp-latest \
@@ -96,13 +101,18 @@ var rootCmd = &cobra.Command{
 var logger = stderrLogger{stderr: os.Stderr}
 
 type rootFlags struct {
-	kubeconfig   string
-	kubecontext  string
-	namespace    string
-	timeout      time.Duration
-	verbose      bool
-	pollInterval time.Duration
-	defaults     install.Options
+	kubeconfig     string
+	kubecontext    string
+	namespace      string
+	timeout        time.Duration
+	authTimeout    time.Duration
+	verbose        bool
+	pollInterval   time.Duration
+	color          flags.Color
+	fieldManager   string
+	forceConflicts bool
+	webhookRetry   bool
+	defaults       install.Options
 }
 
 var rootArgs = NewRootFlags()
@@ -110,13 +120,25 @@ var rootArgs = NewRootFlags()
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&rootArgs.namespace, "namespace", "n", rootArgs.defaults.Namespace, "the namespace scope for this operation")
 	rootCmd.PersistentFlags().DurationVar(&rootArgs.timeout, "timeout", 5*time.Minute, "timeout for this operation")
+	rootCmd.PersistentFlags().DurationVar(&rootArgs.authTimeout, "auth-timeout", 2*time.Minute,
+		"timeout for the kubeconfig exec plugin to return credentials, e.g. while completing an interactive login, set to 0 to disable")
 	rootCmd.PersistentFlags().BoolVar(&rootArgs.verbose, "verbose", false, "print generated objects")
+	rootCmd.PersistentFlags().StringVar(&rootArgs.fieldManager, "field-manager", utils.DefaultFieldManager,
+		"the name of the manager used to track field ownership of the objects written to the cluster")
+	rootCmd.PersistentFlags().BoolVar(&rootArgs.forceConflicts, "force-conflicts", false,
+		"take ownership of the fields managed by other field managers, install and bootstrap switch to server-side apply")
+	rootCmd.PersistentFlags().BoolVar(&rootArgs.webhookRetry, "retry-on-webhook-timeout", false,
+		"retry with backoff the writes that failed because an admission webhook timed out or is unavailable")
 	rootCmd.PersistentFlags().StringVarP(&rootArgs.kubecontext, "context", "", "", "kubernetes context to use")
+	rootCmd.PersistentFlags().Var(&rootArgs.color, "color", rootArgs.color.Description())
+
+	cobra.OnInitialize(configureColor, configureFieldManager, configureWebhookRetry, configureProgress)
 }
 
 func NewRootFlags() rootFlags {
 	return rootFlags{
 		pollInterval: 2 * time.Second,
+		color:        flags.ColorAuto,
 		defaults:     install.MakeDefaultOptions(),
 	}
 }
@@ -125,12 +147,52 @@ func main() {
 	log.SetFlags(0)
 	generateDocs()
 	kubeconfigFlag()
-	if err := rootCmd.Execute(); err != nil {
-		logger.Failuref("%v", err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handleSignals(cancel)
+
+	start := time.Now()
+	err := rootCmd.ExecuteContext(ctx)
+	runCommandHooks(start, err)
+	reportResult(err)
+	if err != nil {
+		if ctx.Err() != nil {
+			logger.Failuref("operation cancelled: %v", err)
+		} else {
+			logger.Failuref("%v", err)
+		}
 		os.Exit(1)
 	}
 }
 
+// handleSignals cancels the context of the running command on SIGINT or
+// SIGTERM, which aborts in-flight API calls, kubectl processes and wait
+// loops. A second signal terminates the process immediately.
+func handleSignals(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logger.Waitingf("received %s, cancelling operation", sig)
+		cancel()
+		<-signals
+		os.Exit(130)
+	}()
+}
+
+// configureFieldManager sets the field manager of the Kubernetes clients
+// and kubectl apply commands.
+func configureFieldManager() {
+	utils.SetFieldManager(rootArgs.fieldManager, rootArgs.forceConflicts)
+}
+
+// configureWebhookRetry enables the retries of the writes that failed
+// because an admission webhook could not be reached.
+func configureWebhookRetry() {
+	utils.SetWebhookRetry(rootArgs.webhookRetry)
+}
+
 func kubeconfigFlag() {
 	if home := homeDir(); home != "" {
 		rootCmd.PersistentFlags().StringVarP(&rootArgs.kubeconfig, "kubeconfig", "", filepath.Join(home, ".kube", "config"),
@@ -159,6 +221,16 @@ func generateDocs() {
 	}
 }
 
+// manifestsCacheDir returns the directory where the install manifests of
+// released versions are cached, caching is disabled if it can't be determined.
+func manifestsCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "flux", "manifests")
+}
+
 func homeDir() string {
 	if h := os.Getenv("HOME"); h != "" {
 		return h
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/pkg/log"
+)
+
+// progressFormat is set by the --progress flag of the long-running commands.
+var progressFormat = flags.ProgressFormat(flags.ProgressText)
+
+func addProgressFlag(fs *pflag.FlagSet) {
+	fs.Var(&progressFormat, "progress", progressFormat.Description())
+}
+
+// configureProgress replaces the log lines with a stream of JSON events
+// written to stdout when --progress=json-stream is set.
+func configureProgress() {
+	if progressFormat == flags.ProgressJSONStream {
+		logger.reporter = log.NewJSONStreamReporter(os.Stdout)
+	}
+}
+
+// reportedConditions holds the last reported state of each condition,
+// keyed by resource and condition type.
+var reportedConditions = map[string]log.Condition{}
+
+// reportConditions emits an event for each condition of a resource that
+// changed since it was last reported. It is a no-op for the text progress,
+// the log lines already tell the outcome of the reconciliation.
+func reportConditions(kind string, namespacedName types.NamespacedName, conditions []metav1.Condition) {
+	if logger.reporter == nil {
+		return
+	}
+	ref := &log.ResourceRef{Kind: kind, Namespace: namespacedName.Namespace, Name: namespacedName.Name}
+	for _, c := range conditions {
+		condition := log.Condition{
+			Type:    c.Type,
+			Status:  string(c.Status),
+			Reason:  c.Reason,
+			Message: c.Message,
+		}
+		key := fmt.Sprintf("%s/%s", ref, c.Type)
+		if last, ok := reportedConditions[key]; ok && last == condition {
+			continue
+		}
+		reportedConditions[key] = condition
+		logger.reporter.Report(log.Event{
+			Type:      log.ConditionEvent,
+			Message:   fmt.Sprintf("%s condition changed", c.Type),
+			Resource:  ref,
+			Condition: &condition,
+		})
+	}
+}
+
+// reportResult emits the final event of the JSON stream with the outcome
+// of the command.
+func reportResult(cmdErr error) {
+	if logger.reporter == nil {
+		return
+	}
+	message := "succeeded"
+	if cmdErr != nil {
+		message = cmdErr.Error()
+	}
+	logger.reporter.Report(log.Event{Type: log.ResultEvent, Message: message})
+}
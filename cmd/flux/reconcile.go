@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/dependency"
 	"github.com/spf13/cobra"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,6 +31,7 @@ import (
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/fluxcd/flux2/internal/flags"
 	"github.com/fluxcd/flux2/internal/utils"
 )
 
@@ -39,7 +41,18 @@ var reconcileCmd = &cobra.Command{
 	Long:  "The reconcile sub-commands trigger a reconciliation of sources and resources.",
 }
 
+type reconcileFlags struct {
+	timeoutBehavior flags.TimeoutBehavior
+}
+
+var reconcileArgs = reconcileFlags{
+	timeoutBehavior: flags.TimeoutFail,
+}
+
 func init() {
+	reconcileCmd.PersistentFlags().Var(&reconcileArgs.timeoutBehavior, "timeout-behavior", reconcileArgs.timeoutBehavior.Description())
+	addNotifyFlag(reconcileCmd.PersistentFlags())
+	addProgressFlag(reconcileCmd.PersistentFlags())
 	rootCmd.AddCommand(reconcileCmd)
 }
 
@@ -69,10 +82,10 @@ func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
 	}
 	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -100,8 +113,8 @@ func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
 	lastHandledReconcileAt := reconcile.object.lastHandledReconcileRequest()
 	logger.Waitingf("waiting for %s reconciliation", reconcile.kind)
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		reconciliationHandled(ctx, kubeClient, namespacedName, reconcile.object, lastHandledReconcileAt)); err != nil {
-		return err
+		reconciliationHandled(ctx, kubeClient, reconcile.kind, namespacedName, reconcile.object, lastHandledReconcileAt)); err != nil {
+		return describeOnTimeout(kubeClient, reconcile.object.asClientObject(), err)
 	}
 	logger.Successf("%s reconciliation completed", reconcile.kind)
 
@@ -112,13 +125,14 @@ func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func reconciliationHandled(ctx context.Context, kubeClient client.Client,
+func reconciliationHandled(ctx context.Context, kubeClient client.Client, kind string,
 	namespacedName types.NamespacedName, obj reconcilable, lastHandledReconcileAt string) wait.ConditionFunc {
 	return func() (bool, error) {
 		err := kubeClient.Get(ctx, namespacedName, obj.asClientObject())
 		if err != nil {
 			return false, err
 		}
+		reportConditions(kind, namespacedName, *obj.GetStatusConditions())
 		return obj.lastHandledReconcileRequest() != lastHandledReconcileAt, nil
 	}
 }
@@ -140,3 +154,48 @@ func requestReconciliation(ctx context.Context, kubeClient client.Client,
 		return kubeClient.Update(ctx, obj.asClientObject())
 	})
 }
+
+// dependent adapts an object with a spec.dependsOn field to
+// dependency.Dependent, so that it can be sorted by its dependencies.
+type dependent struct {
+	namespacedName types.NamespacedName
+	dependsOn      []dependency.CrossNamespaceDependencyReference
+	suspended      bool
+}
+
+func (d dependent) GetDependsOn() (types.NamespacedName, []dependency.CrossNamespaceDependencyReference) {
+	return d.namespacedName, d.dependsOn
+}
+
+// reconcileInOrder reconciles the objects one after the other, in an order
+// where the dependencies of an object are reconciled before it. Suspended
+// objects are skipped and the first failure stops the reconciliation.
+func reconcileInOrder(kind string, items []dependent, reconcileFn func(name string) error) error {
+	if len(items) == 0 {
+		logger.Failuref("no %s found in %s namespace", kind, rootArgs.namespace)
+		return nil
+	}
+
+	dependents := make([]dependency.Dependent, len(items))
+	suspended := make(map[string]bool)
+	for i, item := range items {
+		dependents[i] = item
+		suspended[item.namespacedName.String()] = item.suspended
+	}
+
+	sorted, err := dependency.Sort(dependents)
+	if err != nil {
+		return fmt.Errorf("unable to order the %s reconciliations: %w", kind, err)
+	}
+
+	for _, ref := range sorted {
+		if suspended[ref.String()] {
+			logger.Waitingf("skipping suspended %s %s", kind, ref.Name)
+			continue
+		}
+		if err := reconcileFn(ref.Name); err != nil {
+			return fmt.Errorf("%s %s: %w", kind, ref.Name, err)
+		}
+	}
+	return nil
+}
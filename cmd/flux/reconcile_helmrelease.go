@@ -47,43 +47,60 @@ The reconcile kustomization command triggers a reconciliation of a HelmRelease r
 
   # Trigger a reconciliation of the HelmRelease's source and apply changes
   flux reconcile hr podinfo --with-source
+
+  # Trigger the reconciliation of all the HelmReleases in the namespace, in dependency order
+  flux reconcile hr --all
 `,
 	RunE: reconcileHrCmdRun,
 }
 
 type reconcileHelmReleaseFlags struct {
 	syncHrWithSource bool
+	all              bool
 }
 
 var rhrArgs reconcileHelmReleaseFlags
 
 func init() {
 	reconcileHrCmd.Flags().BoolVar(&rhrArgs.syncHrWithSource, "with-source", false, "reconcile HelmRelease source")
+	reconcileHrCmd.Flags().BoolVar(&rhrArgs.all, "all", false, "reconcile all the HelmReleases in the namespace, ordered by their dependencies")
 
 	reconcileCmd.AddCommand(reconcileHrCmd)
 }
 
 func reconcileHrCmdRun(cmd *cobra.Command, args []string) error {
-	if len(args) < 1 {
+	if rhrArgs.all {
+		if len(args) > 0 {
+			return fmt.Errorf("a name can't be specified with --all")
+		}
+	} else if len(args) < 1 {
 		return fmt.Errorf("HelmRelease name is required")
 	}
-	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
 
+	if rhrArgs.all {
+		return reconcileAllHelmReleases(ctx, cmd, kubeClient)
+	}
+	return reconcileHelmRelease(ctx, cmd, kubeClient, args[0])
+}
+
+// reconcileHelmRelease requests the reconciliation of a HelmRelease, and
+// of its source with --with-source, then waits for it to finish.
+func reconcileHelmRelease(ctx context.Context, cmd *cobra.Command, kubeClient client.Client, name string) error {
 	namespacedName := types.NamespacedName{
 		Namespace: rootArgs.namespace,
 		Name:      name,
 	}
 
 	var helmRelease helmv2.HelmRelease
-	err = kubeClient.Get(ctx, namespacedName, &helmRelease)
+	err := kubeClient.Get(ctx, namespacedName, &helmRelease)
 	if err != nil {
 		return err
 	}
@@ -95,11 +112,11 @@ func reconcileHrCmdRun(cmd *cobra.Command, args []string) error {
 	if rhrArgs.syncHrWithSource {
 		switch helmRelease.Spec.Chart.Spec.SourceRef.Kind {
 		case sourcev1.HelmRepositoryKind:
-			err = reconcileSourceHelmCmdRun(nil, []string{helmRelease.Spec.Chart.Spec.SourceRef.Name})
+			err = reconcileSourceHelmCmdRun(cmd, []string{helmRelease.Spec.Chart.Spec.SourceRef.Name})
 		case sourcev1.GitRepositoryKind:
-			err = reconcileSourceGitCmdRun(nil, []string{helmRelease.Spec.Chart.Spec.SourceRef.Name})
+			err = reconcileSourceGitCmdRun(cmd, []string{helmRelease.Spec.Chart.Spec.SourceRef.Name})
 		case sourcev1.BucketKind:
-			err = reconcileSourceBucketCmdRun(nil, []string{helmRelease.Spec.Chart.Spec.SourceRef.Name})
+			err = reconcileSourceBucketCmdRun(cmd, []string{helmRelease.Spec.Chart.Spec.SourceRef.Name})
 		}
 		if err != nil {
 			return err
@@ -117,7 +134,7 @@ func reconcileHrCmdRun(cmd *cobra.Command, args []string) error {
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		helmReleaseReconciliationHandled(ctx, kubeClient, namespacedName, &helmRelease, lastHandledReconcileAt),
 	); err != nil {
-		return err
+		return describeOnTimeout(kubeClient, &helmRelease, err)
 	}
 	logger.Successf("HelmRelease reconciliation completed")
 
@@ -143,6 +160,7 @@ func helmReleaseReconciliationHandled(ctx context.Context, kubeClient client.Cli
 		if err != nil {
 			return false, err
 		}
+		reportConditions(helmv2.HelmReleaseKind, namespacedName, helmRelease.Status.Conditions)
 		return helmRelease.Status.LastHandledReconcileAt != lastHandledReconcileAt, nil
 	}
 }
@@ -163,3 +181,25 @@ func requestHelmReleaseReconciliation(ctx context.Context, kubeClient client.Cli
 		return kubeClient.Update(ctx, helmRelease)
 	})
 }
+
+// reconcileAllHelmReleases reconciles the HelmReleases of the namespace, ordered
+// by their dependencies.
+func reconcileAllHelmReleases(ctx context.Context, cmd *cobra.Command, kubeClient client.Client) error {
+	var list helmv2.HelmReleaseList
+	if err := kubeClient.List(ctx, &list, client.InNamespace(rootArgs.namespace)); err != nil {
+		return err
+	}
+
+	var items []dependent
+	for _, item := range list.Items {
+		items = append(items, dependent{
+			namespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name},
+			dependsOn:      item.Spec.DependsOn,
+			suspended:      item.Spec.Suspend,
+		})
+	}
+
+	return reconcileInOrder("HelmRelease", items, func(name string) error {
+		return reconcileHelmRelease(ctx, cmd, kubeClient, name)
+	})
+}
@@ -46,42 +46,59 @@ The reconcile kustomization command triggers a reconciliation of a Kustomization
 
   # Trigger a sync of the Kustomization's source and apply changes
   flux reconcile kustomization podinfo --with-source
+
+  # Trigger the reconciliation of all the Kustomizations in the namespace, in dependency order
+  flux reconcile kustomization --all
 `,
 	RunE: reconcileKsCmdRun,
 }
 
 type reconcileKsFlags struct {
 	syncKsWithSource bool
+	all              bool
 }
 
 var rksArgs reconcileKsFlags
 
 func init() {
 	reconcileKsCmd.Flags().BoolVar(&rksArgs.syncKsWithSource, "with-source", false, "reconcile Kustomization source")
+	reconcileKsCmd.Flags().BoolVar(&rksArgs.all, "all", false, "reconcile all the Kustomizations in the namespace, ordered by their dependencies")
 
 	reconcileCmd.AddCommand(reconcileKsCmd)
 }
 
 func reconcileKsCmdRun(cmd *cobra.Command, args []string) error {
-	if len(args) < 1 {
+	if rksArgs.all {
+		if len(args) > 0 {
+			return fmt.Errorf("a name can't be specified with --all")
+		}
+	} else if len(args) < 1 {
 		return fmt.Errorf("Kustomization name is required")
 	}
-	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
 
+	if rksArgs.all {
+		return reconcileAllKustomizations(ctx, cmd, kubeClient)
+	}
+	return reconcileKustomization(ctx, cmd, kubeClient, args[0])
+}
+
+// reconcileKustomization requests the reconciliation of a Kustomization,
+// and of its source with --with-source, then waits for it to finish.
+func reconcileKustomization(ctx context.Context, cmd *cobra.Command, kubeClient client.Client, name string) error {
 	namespacedName := types.NamespacedName{
 		Namespace: rootArgs.namespace,
 		Name:      name,
 	}
 	var kustomization kustomizev1.Kustomization
-	err = kubeClient.Get(ctx, namespacedName, &kustomization)
+	err := kubeClient.Get(ctx, namespacedName, &kustomization)
 	if err != nil {
 		return err
 	}
@@ -93,9 +110,9 @@ func reconcileKsCmdRun(cmd *cobra.Command, args []string) error {
 	if rksArgs.syncKsWithSource {
 		switch kustomization.Spec.SourceRef.Kind {
 		case sourcev1.GitRepositoryKind:
-			err = reconcileSourceGitCmdRun(nil, []string{kustomization.Spec.SourceRef.Name})
+			err = reconcileSourceGitCmdRun(cmd, []string{kustomization.Spec.SourceRef.Name})
 		case sourcev1.BucketKind:
-			err = reconcileSourceBucketCmdRun(nil, []string{kustomization.Spec.SourceRef.Name})
+			err = reconcileSourceBucketCmdRun(cmd, []string{kustomization.Spec.SourceRef.Name})
 		}
 		if err != nil {
 			return err
@@ -114,7 +131,7 @@ func reconcileKsCmdRun(cmd *cobra.Command, args []string) error {
 		rootArgs.pollInterval, rootArgs.timeout,
 		kustomizeReconciliationHandled(ctx, kubeClient, namespacedName, &kustomization, lastHandledReconcileAt),
 	); err != nil {
-		return err
+		return describeOnTimeout(kubeClient, &kustomization, err)
 	}
 	logger.Successf("Kustomization reconciliation completed")
 
@@ -132,6 +149,7 @@ func kustomizeReconciliationHandled(ctx context.Context, kubeClient client.Clien
 		if err != nil {
 			return false, err
 		}
+		reportConditions(kustomizev1.KustomizationKind, namespacedName, kustomization.Status.Conditions)
 		return kustomization.Status.LastHandledReconcileAt != lastHandledReconcileAt, nil
 	}
 }
@@ -152,3 +170,25 @@ func requestKustomizeReconciliation(ctx context.Context, kubeClient client.Clien
 		return kubeClient.Update(ctx, kustomization)
 	})
 }
+
+// reconcileAllKustomizations reconciles the Kustomizations of the namespace, ordered
+// by their dependencies.
+func reconcileAllKustomizations(ctx context.Context, cmd *cobra.Command, kubeClient client.Client) error {
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list, client.InNamespace(rootArgs.namespace)); err != nil {
+		return err
+	}
+
+	var items []dependent
+	for _, item := range list.Items {
+		items = append(items, dependent{
+			namespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name},
+			dependsOn:      item.Spec.DependsOn,
+			suspended:      item.Spec.Suspend,
+		})
+	}
+
+	return reconcileInOrder("Kustomization", items, func(name string) error {
+		return reconcileKustomization(ctx, cmd, kubeClient, name)
+	})
+}
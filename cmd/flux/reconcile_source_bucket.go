@@ -42,11 +42,29 @@ var reconcileSourceBucketCmd = &cobra.Command{
 	Long:  `The reconcile source command triggers a reconciliation of a Bucket resource and waits for it to finish.`,
 	Example: `  # Trigger a reconciliation for an existing source
   flux reconcile source bucket podinfo
+
+  # Print the conditions, events and controller logs of the source if the reconciliation times out
+  flux reconcile source bucket podinfo --timeout=1m --timeout-behavior=describe
+
+  # Refresh the access keys from a local AWS profile if the reconciliation fails with an auth error
+  flux reconcile source bucket podinfo --provider-refresh-credentials --aws-profile=flux-buckets
 `,
 	RunE: reconcileSourceBucketCmdRun,
 }
 
+type reconcileSourceBucketFlags struct {
+	refreshCredentials bool
+	awsProfile         string
+}
+
+var reconcileSourceBucketArgs reconcileSourceBucketFlags
+
 func init() {
+	reconcileSourceBucketCmd.Flags().BoolVar(&reconcileSourceBucketArgs.refreshCredentials, "provider-refresh-credentials", false,
+		"when the reconciliation fails with an auth error, refresh the credentials from the local cloud provider CLI, "+
+			"update the Bucket secret and retry")
+	reconcileSourceBucketCmd.Flags().StringVar(&reconcileSourceBucketArgs.awsProfile, "aws-profile", "",
+		"local AWS profile used by --provider-refresh-credentials, when not set a confirmation is asked before using the default profile")
 	reconcileSourceCmd.AddCommand(reconcileSourceBucketCmd)
 }
 
@@ -56,10 +74,10 @@ func reconcileSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -78,9 +96,39 @@ func reconcileSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("resource is suspended")
 	}
 
+	if err := reconcileBucket(ctx, kubeClient, namespacedName, &bucket); err != nil {
+		return err
+	}
+
+	if apimeta.IsStatusConditionFalse(bucket.Status.Conditions, meta.ReadyCondition) {
+		if !reconcileSourceBucketArgs.refreshCredentials || !isBucketAuthError(&bucket) {
+			return fmt.Errorf("Bucket source reconciliation failed")
+		}
+
+		logger.Actionf("refreshing credentials in secret %s", bucket.Spec.SecretRef.Name)
+		if err := refreshBucketCredentials(ctx, kubeClient, &bucket); err != nil {
+			return fmt.Errorf("credentials refresh failed: %w", err)
+		}
+		logger.Successf("credentials refreshed")
+
+		if err := reconcileBucket(ctx, kubeClient, namespacedName, &bucket); err != nil {
+			return err
+		}
+		if apimeta.IsStatusConditionFalse(bucket.Status.Conditions, meta.ReadyCondition) {
+			return fmt.Errorf("Bucket source reconciliation failed")
+		}
+	}
+	logger.Successf("fetched revision %s", bucket.Status.Artifact.Revision)
+	return nil
+}
+
+// reconcileBucket requests the reconciliation of a bucket and waits for
+// the controller to handle it.
+func reconcileBucket(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName, bucket *sourcev1.Bucket) error {
 	lastHandledReconcileAt := bucket.Status.LastHandledReconcileAt
-	logger.Actionf("annotating Bucket source %s in %s namespace", name, rootArgs.namespace)
-	if err := requestBucketReconciliation(ctx, kubeClient, namespacedName, &bucket); err != nil {
+	logger.Actionf("annotating Bucket source %s in %s namespace", namespacedName.Name, namespacedName.Namespace)
+	if err := requestBucketReconciliation(ctx, kubeClient, namespacedName, bucket); err != nil {
 		return err
 	}
 	logger.Successf("Bucket source annotated")
@@ -88,16 +136,11 @@ func reconcileSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Waitingf("waiting for Bucket source reconciliation")
 	if err := wait.PollImmediate(
 		rootArgs.pollInterval, rootArgs.timeout,
-		bucketReconciliationHandled(ctx, kubeClient, namespacedName, &bucket, lastHandledReconcileAt),
+		bucketReconciliationHandled(ctx, kubeClient, namespacedName, bucket, lastHandledReconcileAt),
 	); err != nil {
-		return err
+		return describeOnTimeout(kubeClient, bucket, err)
 	}
 	logger.Successf("Bucket source reconciliation completed")
-
-	if apimeta.IsStatusConditionFalse(bucket.Status.Conditions, meta.ReadyCondition) {
-		return fmt.Errorf("Bucket source reconciliation failed")
-	}
-	logger.Successf("fetched revision %s", bucket.Status.Artifact.Revision)
 	return nil
 }
 
@@ -133,6 +176,7 @@ func bucketReconciliationHandled(ctx context.Context, kubeClient client.Client,
 		if err != nil {
 			return false, err
 		}
+		reportConditions(sourcev1.BucketKind, namespacedName, bucket.Status.Conditions)
 		return bucket.Status.LastHandledReconcileAt != lastHandledReconcileAt, nil
 	}
 }
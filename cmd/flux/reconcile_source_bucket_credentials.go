@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+// bucketAuthErrors are the S3 error codes returned for missing, invalid
+// or expired credentials.
+var bucketAuthErrors = []string{
+	"AccessDenied",
+	"Access Denied",
+	"InvalidAccessKeyId",
+	"SignatureDoesNotMatch",
+	"ExpiredToken",
+	"TokenRefreshRequired",
+}
+
+// isBucketAuthError tells if the last reconciliation of a bucket failed
+// because of its credentials.
+func isBucketAuthError(bucket *sourcev1.Bucket) bool {
+	c := apimeta.FindStatusCondition(bucket.Status.Conditions, meta.ReadyCondition)
+	if c == nil {
+		return false
+	}
+	for _, code := range bucketAuthErrors {
+		if strings.Contains(c.Message, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// bucketCredentialsProvider runs a local credential flow for the given
+// profile and returns the access key and secret key to store in the
+// Bucket secret.
+type bucketCredentialsProvider func(ctx context.Context, profile string) (string, string, error)
+
+// bucketCredentialsProviders are matched against the bucket endpoint.
+// The Bucket API only accepts static access keys, the providers which
+// issue tokens (Azure AD, GCP OAuth) can't be used to refresh them.
+var bucketCredentialsProviders = map[string]bucketCredentialsProvider{
+	"amazonaws.com": awsBucketCredentials,
+}
+
+// awsBucketCredentials exports the credentials of a local AWS profile
+// with the AWS CLI, the default profile is used when none is given.
+func awsBucketCredentials(ctx context.Context, profile string) (string, string, error) {
+	args := []string{"configure", "export-credentials", "--format", "process"}
+	if profile != "" {
+		args = append(args, "--profile", profile)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "aws", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("aws configure export-credentials failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	var creds struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		SessionToken    string `json:"SessionToken"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return "", "", fmt.Errorf("aws credentials can't be decoded: %w", err)
+	}
+	if creds.SessionToken != "" {
+		return "", "", fmt.Errorf("the local AWS profile issues temporary STS credentials, " +
+			"the Bucket API requires long-lived access keys")
+	}
+	return creds.AccessKeyID, creds.SecretAccessKey, nil
+}
+
+// bucketCredentialKeys are the keys of the Bucket secret replaced by a
+// credentials refresh.
+var bucketCredentialKeys = []string{"accesskey", "secretkey"}
+
+// refreshBucketCredentials runs the local credential flow of the bucket
+// provider and updates the secret referenced by the bucket. The local
+// credentials are long-lived, so the keys they replace are printed and,
+// unless a profile is explicitly given, a confirmation is asked first.
+func refreshBucketCredentials(ctx context.Context, kubeClient client.Client, bucket *sourcev1.Bucket) error {
+	if bucket.Spec.SecretRef == nil {
+		return fmt.Errorf("Bucket source has no secret reference")
+	}
+
+	var provider bucketCredentialsProvider
+	endpoint := strings.Split(bucket.Spec.Endpoint, ":")[0]
+	for domain, p := range bucketCredentialsProviders {
+		if endpoint == domain || strings.HasSuffix(endpoint, "."+domain) {
+			provider = p
+		}
+	}
+	if provider == nil {
+		return fmt.Errorf("credentials refresh is not supported for endpoint %s", bucket.Spec.Endpoint)
+	}
+
+	var secret corev1.Secret
+	namespacedName := types.NamespacedName{Namespace: bucket.Namespace, Name: bucket.Spec.SecretRef.Name}
+	if err := kubeClient.Get(ctx, namespacedName, &secret); err != nil {
+		return err
+	}
+
+	profile := reconcileSourceBucketArgs.awsProfile
+	if profile == "" {
+		profile = "default"
+	}
+	for _, key := range bucketCredentialKeys {
+		if _, ok := secret.Data[key]; ok {
+			logger.Waitingf("key %s of secret %s will be replaced with the credentials of the local profile %s", key, namespacedName, profile)
+		} else {
+			logger.Waitingf("key %s of secret %s will be set with the credentials of the local profile %s", key, namespacedName, profile)
+		}
+	}
+	if reconcileSourceBucketArgs.awsProfile == "" {
+		prompt := promptui.Prompt{
+			Label:     fmt.Sprintf("Are you sure you want to store the long-lived credentials of the %s profile in the cluster", profile),
+			IsConfirm: true,
+		}
+		if _, err := prompt.Run(); err != nil {
+			return fmt.Errorf("aborting")
+		}
+	}
+
+	accessKey, secretKey, err := provider(ctx, reconcileSourceBucketArgs.awsProfile)
+	if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data["accesskey"] = []byte(accessKey)
+	secret.Data["secretkey"] = []byte(secretKey)
+	return kubeClient.Update(ctx, &secret)
+}
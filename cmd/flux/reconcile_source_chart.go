@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/fluxcd/flux2/internal/utils"
+
+	"github.com/spf13/cobra"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+var reconcileSourceHelmChartCmd = &cobra.Command{
+	Use:   "chart [name]",
+	Short: "Reconcile a HelmChart source",
+	Long:  `The reconcile source command triggers a reconciliation of a HelmChart resource and waits for it to finish.`,
+	Example: `  # Trigger a reconciliation for an existing HelmChart
+  flux reconcile source chart podinfo
+
+  # Wait for a new chart artifact, e.g. after the values file has been changed in Git
+  flux reconcile source chart podinfo --reset-chart
+`,
+	RunE: reconcileSourceHelmChartCmdRun,
+}
+
+type reconcileSourceHelmChartFlags struct {
+	resetChart bool
+}
+
+var rscArgs reconcileSourceHelmChartFlags
+
+func init() {
+	reconcileSourceHelmChartCmd.Flags().BoolVar(&rscArgs.resetChart, "reset-chart", false,
+		"wait for source-controller to package a new chart artifact after the reconciliation")
+	reconcileSourceCmd.AddCommand(reconcileSourceHelmChartCmd)
+}
+
+func reconcileSourceHelmChartCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("HelmChart source name is required")
+	}
+	name := args[0]
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	var chart sourcev1.HelmChart
+	err = kubeClient.Get(ctx, namespacedName, &chart)
+	if err != nil {
+		return err
+	}
+
+	if chart.Spec.Suspend {
+		return fmt.Errorf("resource is suspended")
+	}
+
+	var lastArtifactUpdate metav1.Time
+	if chart.Status.Artifact != nil {
+		lastArtifactUpdate = chart.Status.Artifact.LastUpdateTime
+	}
+
+	logger.Actionf("annotating HelmChart source %s in %s namespace", name, rootArgs.namespace)
+	if err := requestHelmChartReconciliation(ctx, kubeClient, namespacedName, &chart); err != nil {
+		return err
+	}
+	logger.Successf("HelmChart source annotated")
+
+	lastHandledReconcileAt := chart.Status.LastHandledReconcileAt
+	logger.Waitingf("waiting for HelmChart source reconciliation")
+	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+		helmChartReconciliationHandled(ctx, kubeClient, namespacedName, &chart, lastHandledReconcileAt)); err != nil {
+		return describeOnTimeout(kubeClient, &chart, err)
+	}
+
+	if rscArgs.resetChart {
+		logger.Waitingf("waiting for HelmChart artifact to be rebuilt")
+		if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+			helmChartArtifactRebuilt(ctx, kubeClient, namespacedName, &chart, lastArtifactUpdate)); err != nil {
+			return describeOnTimeout(kubeClient, &chart, err)
+		}
+	}
+	logger.Successf("HelmChart source reconciliation completed")
+
+	if apimeta.IsStatusConditionFalse(chart.Status.Conditions, meta.ReadyCondition) {
+		return fmt.Errorf("HelmChart source reconciliation failed")
+	}
+	logger.Successf("fetched revision %s", chart.Status.Artifact.Revision)
+	return nil
+}
+
+func helmChartReconciliationHandled(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName, chart *sourcev1.HelmChart, lastHandledReconcileAt string) wait.ConditionFunc {
+	return func() (bool, error) {
+		err := kubeClient.Get(ctx, namespacedName, chart)
+		if err != nil {
+			return false, err
+		}
+		reportConditions(sourcev1.HelmChartKind, namespacedName, chart.Status.Conditions)
+		return chart.Status.LastHandledReconcileAt != lastHandledReconcileAt, nil
+	}
+}
+
+// helmChartArtifactRebuilt waits for an artifact newer than the one
+// recorded before the reconciliation was requested, or fails if the
+// chart could not be packaged.
+func helmChartArtifactRebuilt(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName, chart *sourcev1.HelmChart, lastArtifactUpdate metav1.Time) wait.ConditionFunc {
+	return func() (bool, error) {
+		err := kubeClient.Get(ctx, namespacedName, chart)
+		if err != nil {
+			return false, err
+		}
+
+		if c := apimeta.FindStatusCondition(chart.Status.Conditions, meta.ReadyCondition); c != nil &&
+			c.Status == metav1.ConditionFalse && chart.Generation == chart.Status.ObservedGeneration {
+			return false, errors.New(c.Message)
+		}
+		return chart.Status.Artifact != nil && lastArtifactUpdate.Before(&chart.Status.Artifact.LastUpdateTime), nil
+	}
+}
+
+func requestHelmChartReconciliation(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName, chart *sourcev1.HelmChart) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		if err := kubeClient.Get(ctx, namespacedName, chart); err != nil {
+			return err
+		}
+		if chart.Annotations == nil {
+			chart.Annotations = map[string]string{
+				meta.ReconcileRequestAnnotation: time.Now().Format(time.RFC3339Nano),
+			}
+		} else {
+			chart.Annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+		}
+		return kubeClient.Update(ctx, chart)
+	})
+}
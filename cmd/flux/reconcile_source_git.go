@@ -54,10 +54,10 @@ func reconcileSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -86,7 +86,7 @@ func reconcileSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Waitingf("waiting for GitRepository source reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		gitRepositoryReconciliationHandled(ctx, kubeClient, namespacedName, &repository, lastHandledReconcileAt)); err != nil {
-		return err
+		return describeOnTimeout(kubeClient, &repository, err)
 	}
 	logger.Successf("GitRepository source reconciliation completed")
 
@@ -104,6 +104,7 @@ func gitRepositoryReconciliationHandled(ctx context.Context, kubeClient client.C
 		if err != nil {
 			return false, err
 		}
+		reportConditions(sourcev1.GitRepositoryKind, namespacedName, repository.Status.Conditions)
 		return repository.Status.LastHandledReconcileAt != lastHandledReconcileAt, nil
 	}
 }
@@ -55,10 +55,10 @@ func reconcileSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -87,7 +87,7 @@ func reconcileSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Waitingf("waiting for HelmRepository source reconciliation")
 	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		helmRepositoryReconciliationHandled(ctx, kubeClient, namespacedName, &repository, lastHandledReconcileAt)); err != nil {
-		return err
+		return describeOnTimeout(kubeClient, &repository, err)
 	}
 	logger.Successf("HelmRepository source reconciliation completed")
 
@@ -105,6 +105,7 @@ func helmRepositoryReconciliationHandled(ctx context.Context, kubeClient client.
 		if err != nil {
 			return false, err
 		}
+		reportConditions(sourcev1.HelmRepositoryKind, namespacedName, repository.Status.Conditions)
 		return repository.Status.LastHandledReconcileAt != lastHandledReconcileAt, nil
 	}
 }
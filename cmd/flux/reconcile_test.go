@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/dependency"
+)
+
+// reconcilingClient plays the part of the controllers: the objects
+// annotated with a reconcile request are marked as handled and ready.
+type reconcilingClient struct {
+	client.Client
+	reconciled []string
+}
+
+func (c *reconcilingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	requestedAt := obj.GetAnnotations()[meta.ReconcileRequestAnnotation]
+	ready := metav1.Condition{
+		Type:               meta.ReadyCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             meta.ReconciliationSucceededReason,
+		LastTransitionTime: metav1.Now(),
+	}
+	switch o := obj.(type) {
+	case *kustomizev1.Kustomization:
+		o.Status.LastHandledReconcileAt = requestedAt
+		o.Status.Conditions = []metav1.Condition{ready}
+	case *helmv2.HelmRelease:
+		o.Status.LastHandledReconcileAt = requestedAt
+		o.Status.Conditions = []metav1.Condition{ready}
+	}
+	c.reconciled = append(c.reconciled, obj.GetName())
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func newReconcilingClient(objs ...apiruntime.Object) *reconcilingClient {
+	scheme := apiruntime.NewScheme()
+	_ = kustomizev1.AddToScheme(scheme)
+	_ = helmv2.AddToScheme(scheme)
+	return &reconcilingClient{Client: fake.NewFakeClientWithScheme(scheme, objs...)}
+}
+
+func setReconcileTestArgs(t *testing.T) {
+	namespace, pollInterval, timeout := rootArgs.namespace, rootArgs.pollInterval, rootArgs.timeout
+	ksAll, hrAll := rksArgs.all, rhrArgs.all
+	t.Cleanup(func() {
+		rootArgs.namespace, rootArgs.pollInterval, rootArgs.timeout = namespace, pollInterval, timeout
+		rksArgs.all, rhrArgs.all = ksAll, hrAll
+	})
+	rootArgs.namespace = "apps"
+	rootArgs.pollInterval = 10 * time.Millisecond
+	rootArgs.timeout = 5 * time.Second
+	rksArgs.all, rhrArgs.all = true, true
+}
+
+func TestReconcileAllKustomizations(t *testing.T) {
+	setReconcileTestArgs(t)
+
+	kustomization := func(name string, suspend bool, dependsOn ...string) *kustomizev1.Kustomization {
+		k := &kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "apps"},
+			Spec:       kustomizev1.KustomizationSpec{Suspend: suspend},
+		}
+		for _, dep := range dependsOn {
+			k.Spec.DependsOn = append(k.Spec.DependsOn, dependency.CrossNamespaceDependencyReference{Name: dep})
+		}
+		return k
+	}
+	kubeClient := newReconcilingClient(
+		kustomization("frontend", false, "backend"),
+		kustomization("backend", false, "infra"),
+		kustomization("infra", false),
+		kustomization("paused", true),
+	)
+
+	if err := reconcileAllKustomizations(context.TODO(), &cobra.Command{}, kubeClient); err != nil {
+		t.Fatalf("reconcileAllKustomizations() error = %v", err)
+	}
+	expected := []string{"infra", "backend", "frontend"}
+	if !reflect.DeepEqual(kubeClient.reconciled, expected) {
+		t.Errorf("reconciled = %v, expected %v", kubeClient.reconciled, expected)
+	}
+}
+
+func TestReconcileAllHelmReleases(t *testing.T) {
+	setReconcileTestArgs(t)
+
+	helmRelease := func(name string, dependsOn ...string) *helmv2.HelmRelease {
+		hr := &helmv2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "apps"},
+		}
+		for _, dep := range dependsOn {
+			hr.Spec.DependsOn = append(hr.Spec.DependsOn, dependency.CrossNamespaceDependencyReference{Name: dep})
+		}
+		return hr
+	}
+	kubeClient := newReconcilingClient(
+		helmRelease("podinfo", "redis"),
+		helmRelease("redis"),
+	)
+
+	if err := reconcileAllHelmReleases(context.TODO(), &cobra.Command{}, kubeClient); err != nil {
+		t.Fatalf("reconcileAllHelmReleases() error = %v", err)
+	}
+	expected := []string{"redis", "podinfo"}
+	if !reflect.DeepEqual(kubeClient.reconciled, expected) {
+		t.Errorf("reconciled = %v, expected %v", kubeClient.reconciled, expected)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+const (
+	describeEventsLimit = 10
+	describeLogsLimit   = 10
+	describeLogsTail    = int64(1000)
+)
+
+// describeOnTimeout prints the conditions, the last events and the recent
+// controller log lines of the object when waiting for it timed out and
+// --timeout-behavior=describe is set. The wait error is returned as is.
+func describeOnTimeout(kubeClient client.Client, obj client.Object, err error) error {
+	if err == nil || reconcileArgs.timeoutBehavior != flags.TimeoutDescribe {
+		return err
+	}
+	if !errors.Is(err, wait.ErrWaitTimeout) && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	// the command context has expired at this point
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	gvk, gvkErr := apiutil.GVKForObject(obj, kubeClient.Scheme())
+	if gvkErr != nil {
+		return err
+	}
+	logger.Failuref("timed out waiting for %s %s/%s", gvk.Kind, obj.GetNamespace(), obj.GetName())
+
+	logger.Actionf("conditions")
+	printObjectConditions(obj)
+
+	logger.Actionf("last events")
+	if eventsErr := printObjectEvents(ctx, kubeClient, gvk.Kind, obj); eventsErr != nil {
+		logger.Failuref("unable to list events: %s", eventsErr.Error())
+	}
+
+	controller := controllerForGroup(gvk.Group, gvk.Kind)
+	logger.Actionf("%s logs", controller)
+	if logsErr := printControllerLogs(ctx, controller, obj); logsErr != nil {
+		logger.Failuref("unable to get logs: %s", logsErr.Error())
+	}
+	return err
+}
+
+func printObjectConditions(obj client.Object) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return
+	}
+	status, _ := content["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	if len(conditions) == 0 {
+		fmt.Fprintln(os.Stderr, "  no conditions reported")
+		return
+	}
+	for _, c := range conditions {
+		condition, _ := c.(map[string]interface{})
+		fmt.Fprintf(os.Stderr, "  %v=%v %v: %v\n", condition["type"], condition["status"], condition["reason"], condition["message"])
+	}
+}
+
+func printObjectEvents(ctx context.Context, kubeClient client.Client, kind string, obj client.Object) error {
+	var events corev1.EventList
+	if err := kubeClient.List(ctx, &events, client.InNamespace(obj.GetNamespace()), client.MatchingFields{
+		"involvedObject.kind": kind,
+		"involvedObject.name": obj.GetName(),
+	}); err != nil {
+		return err
+	}
+	if len(events.Items) == 0 {
+		fmt.Fprintln(os.Stderr, "  no events found")
+		return nil
+	}
+
+	sort.Slice(events.Items, func(i, j int) bool {
+		return eventTime(events.Items[i]).Before(eventTime(events.Items[j]))
+	})
+	if len(events.Items) > describeEventsLimit {
+		events.Items = events.Items[len(events.Items)-describeEventsLimit:]
+	}
+	for _, e := range events.Items {
+		fmt.Fprintf(os.Stderr, "  %s %s %s: %s\n", eventTime(e).Format(time.RFC3339), e.Type, e.Reason, strings.TrimSpace(e.Message))
+	}
+	return nil
+}
+
+func eventTime(e corev1.Event) time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	return e.EventTime.Time
+}
+
+// printControllerLogs prints the recent log lines of the controller pods
+// which mention the object.
+func printControllerLogs(ctx context.Context, controller string, obj client.Object) error {
+	cfg, err := utils.KubeConfig(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", controller),
+	})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		fmt.Fprintf(os.Stderr, "  no %s pods found\n", controller)
+		return nil
+	}
+
+	name := fmt.Sprintf(`"name":"%s"`, obj.GetName())
+	namespace := fmt.Sprintf(`"namespace":"%s"`, obj.GetNamespace())
+	var lines []string
+	for _, pod := range pods.Items {
+		tail := describeLogsTail
+		stream, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container: "manager",
+			TailLines: &tail,
+		}).Stream(ctx)
+		if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(stream)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.Contains(line, name) && strings.Contains(line, namespace) {
+				lines = append(lines, line)
+			}
+		}
+		stream.Close()
+	}
+
+	if len(lines) == 0 {
+		fmt.Fprintln(os.Stderr, "  no log lines found")
+		return nil
+	}
+	if len(lines) > describeLogsLimit {
+		lines = lines[len(lines)-describeLogsLimit:]
+	}
+	for _, line := range lines {
+		fmt.Fprintf(os.Stderr, "  %s\n", line)
+	}
+	return nil
+}
+
+// controllerForGroup returns the name of the controller reconciling a kind.
+func controllerForGroup(group, kind string) string {
+	switch {
+	case strings.HasPrefix(group, "source."):
+		return "source-controller"
+	case strings.HasPrefix(group, "kustomize."):
+		return "kustomize-controller"
+	case strings.HasPrefix(group, "helm."):
+		return "helm-controller"
+	case strings.HasPrefix(group, "notification."):
+		return "notification-controller"
+	case kind == "ImageUpdateAutomation":
+		return "image-automation-controller"
+	default:
+		return "image-reflector-controller"
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// The phases recorded in the status file of the bootstrap, install and
+// uninstall commands.
+const (
+	phaseRepositoryPrepared = "RepositoryPrepared"
+	phaseManifestsGenerated = "ManifestsGenerated"
+	phaseManifestsCommitted = "ManifestsCommitted"
+	phaseComponentsApplied  = "ComponentsApplied"
+	phaseComponentsHealthy  = "ComponentsHealthy"
+	phaseKeyConfigured      = "KeyConfigured"
+	phaseSyncVerified       = "SyncVerified"
+	phaseResourcesDeleted   = "ResourcesDeleted"
+	phaseComponentsDeleted  = "ComponentsDeleted"
+)
+
+// The states of an operation recorded in the status file.
+const (
+	statusInProgress = "InProgress"
+	statusSucceeded  = "Succeeded"
+	statusFailed     = "Failed"
+)
+
+func addStatusFileFlag(flags *pflag.FlagSet, path *string) {
+	flags.StringVar(path, "status-file", "",
+		"path to a JSON file updated with the completed phases of the operation, for use by orchestration tools")
+}
+
+type phaseStatus struct {
+	Name        string    `json:"name"`
+	Message     string    `json:"message,omitempty"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+type operationStatus struct {
+	Command   string        `json:"command"`
+	State     string        `json:"state"`
+	Phases    []phaseStatus `json:"phases"`
+	Error     string        `json:"error,omitempty"`
+	StartedAt time.Time     `json:"startedAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
+
+// statusFile records the progress of an operation and writes it to a
+// JSON file after each phase, the file is not written when no path is set.
+type statusFile struct {
+	path   string
+	status operationStatus
+}
+
+func newStatusFile(path, command string) *statusFile {
+	now := time.Now().UTC()
+	s := &statusFile{
+		path: path,
+		status: operationStatus{
+			Command:   command,
+			State:     statusInProgress,
+			Phases:    []phaseStatus{},
+			StartedAt: now,
+			UpdatedAt: now,
+		},
+	}
+	s.write()
+	return s
+}
+
+// phase records the completion of a phase, with an optional message
+// e.g. to tell that the phase was skipped.
+func (s *statusFile) phase(name, message string) {
+	now := time.Now().UTC()
+	s.status.Phases = append(s.status.Phases, phaseStatus{
+		Name:        name,
+		Message:     message,
+		CompletedAt: now,
+	})
+	s.status.UpdatedAt = now
+	s.write()
+}
+
+// finish records the outcome of the operation.
+func (s *statusFile) finish(err error) {
+	s.status.State = statusSucceeded
+	if err != nil {
+		s.status.State = statusFailed
+		s.status.Error = err.Error()
+	}
+	s.status.UpdatedAt = time.Now().UTC()
+	s.write()
+}
+
+// completedPhases returns the names of the phases completed so far.
+func (s *statusFile) completedPhases() []string {
+	var names []string
+	for _, p := range s.status.Phases {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// write replaces the status file atomically, so that readers never see
+// a partial update. Failures are logged without aborting the operation.
+func (s *statusFile) write() {
+	if s.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(s.status, "", "  ")
+	if err != nil {
+		logger.Failuref("status file update failed: %s", err.Error())
+		return
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path))
+	if err != nil {
+		logger.Failuref("status file update failed: %s", err.Error())
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		logger.Failuref("status file update failed: %s", err.Error())
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		logger.Failuref("status file update failed: %s", err.Error())
+		return
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		logger.Failuref("status file update failed: %s", err.Error())
+	}
+}
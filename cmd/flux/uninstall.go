@@ -46,10 +46,11 @@ var uninstallCmd = &cobra.Command{
 }
 
 type uninstallFlags struct {
-	crds      bool
-	resources bool
-	dryRun    bool
-	silent    bool
+	crds       bool
+	resources  bool
+	dryRun     bool
+	silent     bool
+	statusFile string
 }
 
 var uninstallArgs uninstallFlags
@@ -64,14 +65,19 @@ func init() {
 	uninstallCmd.Flags().BoolVarP(&uninstallArgs.silent, "silent", "s", false,
 		"delete components without asking for confirmation")
 
+	addStatusFileFlag(uninstallCmd.Flags(), &uninstallArgs.statusFile)
+
 	rootCmd.AddCommand(uninstallCmd)
 }
 
-func uninstallCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+func uninstallCmdRun(cmd *cobra.Command, args []string) (retErr error) {
+	status := newStatusFile(uninstallArgs.statusFile, cmd.CommandPath())
+	defer func() { status.finish(retErr) }()
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
 	if err != nil {
 		return err
 	}
@@ -126,6 +132,7 @@ func uninstallCmdRun(cmd *cobra.Command, args []string) error {
 				logger.Failuref("kubectl: %s", err.Error())
 			}
 		}
+		status.phase(phaseResourcesDeleted, "")
 	}
 
 	var kinds []string
@@ -151,6 +158,7 @@ func uninstallCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	status.phase(phaseComponentsDeleted, "")
 	logger.Successf("uninstall finished")
 	return nil
 }
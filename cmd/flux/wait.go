@@ -0,0 +1,33 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var waitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Wait for resources to reach a state",
+	Long:  "The wait sub-commands block until a resource reaches the given state or the timeout expires.",
+}
+
+func init() {
+	addNotifyFlag(waitCmd.PersistentFlags())
+	addProgressFlag(waitCmd.PersistentFlags())
+	rootCmd.AddCommand(waitCmd)
+}
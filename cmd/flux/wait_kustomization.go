@@ -0,0 +1,233 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var waitKsCmd = &cobra.Command{
+	Use:     "kustomization [name]",
+	Aliases: []string{"ks"},
+	Short:   "Wait for a Kustomization to apply a revision",
+	Long: `The wait kustomization command blocks until the Kustomization has applied the given revision.
+When the Kustomization source is a GitRepository, a newer commit of the branch that contains the revision
+is accepted too, which is resolved by fetching the repository with the credentials of the source.`,
+	Example: `  # Wait for a commit to be applied, e.g. before running post-deploy tests
+  flux wait kustomization apps --for-revision=main/0a1b2c3d
+
+  # The revision can be given in the branch@sha1:commit format or as a commit SHA
+  flux wait kustomization apps --for-revision=main@sha1:0a1b2c3d --timeout=10m
+`,
+	RunE: waitKsCmdRun,
+}
+
+type waitKsFlags struct {
+	forRevision string
+}
+
+var waitKsArgs waitKsFlags
+
+func init() {
+	waitKsCmd.Flags().StringVar(&waitKsArgs.forRevision, "for-revision", "",
+		"the source revision to wait for, in the format '<branch>/<commit>', '<branch>@sha1:<commit>' or '<commit>'")
+	waitCmd.AddCommand(waitKsCmd)
+}
+
+func waitKsCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("Kustomization name is required")
+	}
+	name := args[0]
+
+	_, wantCommit := parseRevision(waitKsArgs.forRevision)
+	if wantCommit == "" {
+		return fmt.Errorf("--for-revision is required")
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.authTimeout)
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	var kustomization kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, namespacedName, &kustomization); err != nil {
+		return err
+	}
+
+	logger.Waitingf("waiting for Kustomization %s to apply revision %s", name, waitKsArgs.forRevision)
+	checked := map[string]bool{}
+	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout, func() (bool, error) {
+		if err := kubeClient.Get(ctx, namespacedName, &kustomization); err != nil {
+			return false, err
+		}
+		reportConditions(kustomizev1.KustomizationKind, namespacedName, kustomization.Status.Conditions)
+
+		_, attempted := parseRevision(kustomization.Status.LastAttemptedRevision)
+		if commitMatches(attempted, wantCommit) &&
+			apimeta.IsStatusConditionFalse(kustomization.Status.Conditions, meta.ReadyCondition) &&
+			kustomization.Status.ObservedGeneration == kustomization.Generation {
+			c := apimeta.FindStatusCondition(kustomization.Status.Conditions, meta.ReadyCondition)
+			return false, fmt.Errorf("revision %s failed to apply: %s", kustomization.Status.LastAttemptedRevision, c.Message)
+		}
+
+		applied := kustomization.Status.LastAppliedRevision
+		_, appliedCommit := parseRevision(applied)
+		if appliedCommit == "" {
+			return false, nil
+		}
+		if commitMatches(appliedCommit, wantCommit) {
+			return true, nil
+		}
+		if checked[appliedCommit] {
+			return false, nil
+		}
+		checked[appliedCommit] = true
+
+		newer, err := isDescendantCommit(ctx, kubeClient, kustomization, appliedCommit, wantCommit)
+		if err != nil {
+			logger.Failuref("unable to compare revision %s with %s: %s", applied, waitKsArgs.forRevision, err.Error())
+			return false, nil
+		}
+		return newer, nil
+	}); err != nil {
+		return err
+	}
+
+	logger.Successf("Kustomization %s applied revision %s", name, kustomization.Status.LastAppliedRevision)
+	return nil
+}
+
+// parseRevision returns the branch and the commit of a source revision.
+func parseRevision(revision string) (branch, commit string) {
+	if i := strings.Index(revision, "@sha1:"); i >= 0 {
+		return revision[:i], revision[i+len("@sha1:"):]
+	}
+	if i := strings.LastIndex(revision, "/"); i >= 0 {
+		return revision[:i], revision[i+1:]
+	}
+	return "", revision
+}
+
+// commitMatches compares two commit SHAs, one of which can be abbreviated.
+func commitMatches(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+// isDescendantCommit fetches the GitRepository of the Kustomization and
+// returns true if the applied commit has the wanted commit in its history.
+func isDescendantCommit(ctx context.Context, kubeClient client.Client,
+	kustomization kustomizev1.Kustomization, appliedCommit, wantCommit string) (bool, error) {
+	if kustomization.Spec.SourceRef.Kind != sourcev1.GitRepositoryKind {
+		return false, nil
+	}
+
+	sourceNamespace := kustomization.Spec.SourceRef.Namespace
+	if sourceNamespace == "" {
+		sourceNamespace = kustomization.Namespace
+	}
+	var repository sourcev1.GitRepository
+	if err := kubeClient.Get(ctx, types.NamespacedName{
+		Namespace: sourceNamespace,
+		Name:      kustomization.Spec.SourceRef.Name,
+	}, &repository); err != nil {
+		return false, err
+	}
+
+	var secret *corev1.Secret
+	if repository.Spec.SecretRef != nil {
+		secret = &corev1.Secret{}
+		if err := kubeClient.Get(ctx, types.NamespacedName{
+			Namespace: sourceNamespace,
+			Name:      repository.Spec.SecretRef.Name,
+		}, secret); err != nil {
+			return false, err
+		}
+	}
+
+	tmpDir, err := ioutil.TempDir("", repository.Name)
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	auth, err := gitSecretAuth(repository.Spec.URL, secret, tmpDir)
+	if err != nil {
+		return false, err
+	}
+
+	cloneOpts := &gogit.CloneOptions{
+		URL:  repository.Spec.URL,
+		Auth: auth,
+	}
+	if ref := repository.Spec.Reference; ref != nil && ref.Branch != "" {
+		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(ref.Branch)
+		cloneOpts.SingleBranch = true
+	}
+	repo, err := gogit.CloneContext(ctx, memory.NewStorage(), nil, cloneOpts)
+	if err != nil {
+		return false, err
+	}
+
+	commits, err := repo.Log(&gogit.LogOptions{From: plumbing.NewHash(appliedCommit)})
+	if err != nil {
+		return false, err
+	}
+	errFound := errors.New("found")
+	err = commits.ForEach(func(c *object.Commit) error {
+		if strings.HasPrefix(c.Hash.String(), wantCommit) {
+			return errFound
+		}
+		return nil
+	})
+	if err == errFound {
+		return true, nil
+	}
+	return false, err
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+var supportedColorModes = []string{ColorAuto, ColorAlways, ColorNever}
+
+type Color string
+
+func (c *Color) String() string {
+	return string(*c)
+}
+
+func (c *Color) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no color mode given, must be one of: %s",
+			strings.Join(supportedColorModes, ", "))
+	}
+	if !utils.ContainsItemString(supportedColorModes, str) {
+		return fmt.Errorf("unsupported color mode '%s', must be one of: %s",
+			str, strings.Join(supportedColorModes, ", "))
+	}
+	*c = Color(str)
+	return nil
+}
+
+func (c *Color) Type() string {
+	return "color"
+}
+
+func (c *Color) Description() string {
+	return fmt.Sprintf("colorize the output, available options are: (%s)", strings.Join(supportedColorModes, ", "))
+}
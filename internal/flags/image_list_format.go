@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var supportedImageListFormats = []string{"text", "json", "spdx"}
+
+type ImageListFormat string
+
+func (f *ImageListFormat) String() string {
+	return string(*f)
+}
+
+func (f *ImageListFormat) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no image list format given, must be one of: %s",
+			strings.Join(supportedImageListFormats, ", "))
+	}
+	if !utils.ContainsItemString(supportedImageListFormats, str) {
+		return fmt.Errorf("unsupported image list format '%s', must be one of: %s",
+			str, strings.Join(supportedImageListFormats, ", "))
+	}
+	*f = ImageListFormat(str)
+	return nil
+}
+
+func (f *ImageListFormat) Type() string {
+	return "imageListFormat"
+}
+
+func (f *ImageListFormat) Description() string {
+	return fmt.Sprintf("print the container images and their digests instead of installing, available formats are: (%s)",
+		strings.Join(supportedImageListFormats, ", "))
+}
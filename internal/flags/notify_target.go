@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// NotifyDesktop shows a desktop notification.
+const NotifyDesktop = "desktop"
+
+// NotifyTarget is where a completion message is sent, either the desktop
+// or the URL of a webhook.
+type NotifyTarget string
+
+func (n *NotifyTarget) String() string {
+	return string(*n)
+}
+
+func (n *NotifyTarget) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no notification target given, must be '%s' or a webhook URL", NotifyDesktop)
+	}
+	if str != NotifyDesktop {
+		u, err := url.Parse(str)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("unsupported notification target '%s', must be '%s' or an HTTP/S webhook URL", str, NotifyDesktop)
+		}
+	}
+	*n = NotifyTarget(str)
+	return nil
+}
+
+func (n *NotifyTarget) Type() string {
+	return "notifyTarget"
+}
+
+func (n *NotifyTarget) Description() string {
+	return fmt.Sprintf("send a completion message with the result and duration of the operation, "+
+		"to an HTTP/S webhook URL or to the desktop with '%s'", NotifyDesktop)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var supportedOutputFormats = []string{utils.OutputTable, utils.OutputGoTemplate, utils.OutputJSONPath}
+
+type Output struct {
+	Format   string
+	Template string
+}
+
+func (o *Output) String() string {
+	if o.Template == "" {
+		return o.Format
+	}
+	return fmt.Sprintf("%s=%s", o.Format, o.Template)
+}
+
+func (o *Output) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no output format given, please specify %s",
+			o.Description())
+	}
+
+	format, tmpl := str, ""
+	if i := strings.Index(str, "="); i > -1 {
+		format, tmpl = str[:i], str[i+1:]
+	}
+	if !utils.ContainsItemString(supportedOutputFormats, format) {
+		return fmt.Errorf("unsupported output format '%s', must be one of: %s",
+			format, strings.Join(supportedOutputFormats, ", "))
+	}
+	if format == utils.OutputTable && tmpl != "" {
+		return fmt.Errorf("output format '%s' does not accept a template", format)
+	}
+	if format != utils.OutputTable && tmpl == "" {
+		return fmt.Errorf("output format '%s' requires a template, must be in format %s=<template>",
+			format, format)
+	}
+	if err := utils.ValidateOutputTemplate(format, tmpl); err != nil {
+		return fmt.Errorf("invalid %s template: %w", format, err)
+	}
+
+	o.Format = format
+	o.Template = tmpl
+	return nil
+}
+
+func (o *Output) Type() string {
+	return "output"
+}
+
+func (o *Output) Description() string {
+	return fmt.Sprintf("output format, available options are: (%s), "+
+		"templates are given in the format '<format>=<template>'", strings.Join(supportedOutputFormats, ", "))
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"testing"
+)
+
+func TestOutput_Set(t *testing.T) {
+	tests := []struct {
+		name      string
+		str       string
+		expect    string
+		expectErr bool
+	}{
+		{"table", "table", "table", false},
+		{"go-template", "go-template={{.metadata.name}}", "go-template={{.metadata.name}}", false},
+		{"jsonpath", "jsonpath={.items[*].metadata.name}", "jsonpath={.items[*].metadata.name}", false},
+		{"template with equal sign", "go-template={{if eq .kind \"a=b\"}}{{end}}", "go-template={{if eq .kind \"a=b\"}}{{end}}", false},
+		{"table with template", "table={.items}", "", true},
+		{"missing template", "jsonpath", "", true},
+		{"empty template", "go-template=", "", true},
+		{"invalid go-template", "go-template={{.metadata.name", "", true},
+		{"invalid jsonpath", "jsonpath={.items[", "", true},
+		{"unsupported", "yaml", "", true},
+		{"empty", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o Output
+			if err := o.Set(tt.str); (err != nil) != tt.expectErr {
+				t.Errorf("Set() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if str := o.String(); str != tt.expect {
+				t.Errorf("Set() = %v, expect %v", str, tt.expect)
+			}
+		})
+	}
+}
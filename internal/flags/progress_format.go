@@ -0,0 +1,62 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+const (
+	// ProgressText prints the progress as human readable log lines.
+	ProgressText = "text"
+	// ProgressJSONStream writes the progress to stdout as
+	// newline-delimited JSON events.
+	ProgressJSONStream = "json-stream"
+)
+
+var supportedProgressFormats = []string{ProgressText, ProgressJSONStream}
+
+type ProgressFormat string
+
+func (p *ProgressFormat) String() string {
+	return string(*p)
+}
+
+func (p *ProgressFormat) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no progress format given, must be one of: %s",
+			strings.Join(supportedProgressFormats, ", "))
+	}
+	if !utils.ContainsItemString(supportedProgressFormats, str) {
+		return fmt.Errorf("unsupported progress format '%s', must be one of: %s",
+			str, strings.Join(supportedProgressFormats, ", "))
+	}
+	*p = ProgressFormat(str)
+	return nil
+}
+
+func (p *ProgressFormat) Type() string {
+	return "progressFormat"
+}
+
+func (p *ProgressFormat) Description() string {
+	return fmt.Sprintf("how the progress is reported, 'json-stream' writes newline-delimited JSON events to stdout, "+
+		"available options are: (%s)", strings.Join(supportedProgressFormats, ", "))
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+const (
+	// TimeoutFail returns an error when waiting times out.
+	TimeoutFail = "fail"
+	// TimeoutDescribe prints diagnostics about the resource before
+	// returning the error.
+	TimeoutDescribe = "describe"
+)
+
+var supportedTimeoutBehaviors = []string{TimeoutFail, TimeoutDescribe}
+
+type TimeoutBehavior string
+
+func (t *TimeoutBehavior) String() string {
+	return string(*t)
+}
+
+func (t *TimeoutBehavior) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no timeout behavior given, must be one of: %s",
+			strings.Join(supportedTimeoutBehaviors, ", "))
+	}
+	if !utils.ContainsItemString(supportedTimeoutBehaviors, str) {
+		return fmt.Errorf("unsupported timeout behavior '%s', must be one of: %s",
+			str, strings.Join(supportedTimeoutBehaviors, ", "))
+	}
+	*t = TimeoutBehavior(str)
+	return nil
+}
+
+func (t *TimeoutBehavior) Type() string {
+	return "timeoutBehavior"
+}
+
+func (t *TimeoutBehavior) Description() string {
+	return fmt.Sprintf("what to do when waiting for the reconciliation times out, "+
+		"'describe' prints the conditions, events and controller logs of the resource, available options are: (%s)",
+		strings.Join(supportedTimeoutBehaviors, ", "))
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package portforward forwards local ports to the endpoints of the
+// toolkit controllers, e.g. the source-controller artifact server or
+// the notification-controller receiver, through kubectl port-forward.
+// Sessions are shared by all the callers of a Manager, restarted when
+// kubectl exits and stopped when the Manager is closed.
+package portforward
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultStartTimeout is how long to wait for kubectl to report the local
+// port of a new session.
+const DefaultStartTimeout = 30 * time.Second
+
+// Target is a port of a controller, the pods of which are selected with
+// the 'app' label.
+type Target struct {
+	Namespace  string
+	Controller string
+	Port       int
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%s/%s:%d", t.Namespace, t.Controller, t.Port)
+}
+
+// Manager starts and reuses the port-forward sessions, it's safe for
+// concurrent use.
+type Manager struct {
+	kubeClient     client.Client
+	kubeConfigPath string
+	kubeContext    string
+	startTimeout   time.Duration
+
+	mu      sync.Mutex
+	entries map[Target]*entry
+	closed  bool
+}
+
+// entry is the session of a target, ready is closed once the session is
+// started or failed to start.
+type entry struct {
+	ready   chan struct{}
+	session *session
+	err     error
+}
+
+type session struct {
+	cmd     *exec.Cmd
+	address string
+	done    chan struct{}
+}
+
+// NewManager returns a Manager that discovers the controller pods with
+// the given client and runs kubectl with the given kubeconfig and context.
+func NewManager(kubeClient client.Client, kubeConfigPath, kubeContext string) *Manager {
+	return &Manager{
+		kubeClient:     kubeClient,
+		kubeConfigPath: kubeConfigPath,
+		kubeContext:    kubeContext,
+		startTimeout:   DefaultStartTimeout,
+		entries:        make(map[Target]*entry),
+	}
+}
+
+// Address returns the local address, e.g. "127.0.0.1:53127", forwarded
+// to the target. The session is started on the first call and reused by
+// the next ones, unless kubectl exited in the meantime, e.g. because the
+// pod was restarted, in which case a new session is started. The calls
+// for a target wait for the session being started by another call,
+// without blocking the calls for the other targets.
+func (m *Manager) Address(ctx context.Context, target Target) (string, error) {
+	for {
+		m.mu.Lock()
+		if m.closed {
+			m.mu.Unlock()
+			return "", fmt.Errorf("port-forward to %s failed: manager is closed", target)
+		}
+		e, ok := m.entries[target]
+		if !ok {
+			e = &entry{ready: make(chan struct{})}
+			m.entries[target] = e
+			m.mu.Unlock()
+			return m.startEntry(ctx, target, e)
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-e.ready:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+
+		if e.err != nil {
+			// the context of the call that started the session doesn't
+			// apply to this one, try again
+			if errors.Is(e.err, context.Canceled) || errors.Is(e.err, context.DeadlineExceeded) {
+				continue
+			}
+			return "", e.err
+		}
+		select {
+		case <-e.session.done:
+			m.mu.Lock()
+			if m.entries[target] == e {
+				delete(m.entries, target)
+			}
+			m.mu.Unlock()
+		default:
+			return e.session.address, nil
+		}
+	}
+}
+
+// startEntry starts the session of a pending entry, outside of the lock.
+func (m *Manager) startEntry(ctx context.Context, target Target, e *entry) (string, error) {
+	s, err := m.start(ctx, target)
+	if err != nil {
+		err = fmt.Errorf("port-forward to %s failed: %w", target, err)
+	}
+
+	m.mu.Lock()
+	if err == nil && m.closed {
+		s.stop()
+		err = fmt.Errorf("port-forward to %s failed: manager is closed", target)
+	}
+	e.session, e.err = s, err
+	if err != nil {
+		delete(m.entries, target)
+	}
+	close(e.ready)
+	m.mu.Unlock()
+
+	if err != nil {
+		return "", err
+	}
+	return s.address, nil
+}
+
+// Close stops all the sessions, the sessions being started are stopped
+// as soon as they're ready.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	m.closed = true
+	var sessions []*session
+	for target, e := range m.entries {
+		select {
+		case <-e.ready:
+			sessions = append(sessions, e.session)
+			delete(m.entries, target)
+		default:
+		}
+	}
+	m.mu.Unlock()
+
+	for _, s := range sessions {
+		s.stop()
+	}
+}
+
+func (m *Manager) start(ctx context.Context, target Target) (*session, error) {
+	pod, err := m.readyPod(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"port-forward", "--namespace", target.Namespace,
+		fmt.Sprintf("pod/%s", pod), fmt.Sprintf(":%d", target.Port)}
+	if m.kubeConfigPath != "" && len(filepath.SplitList(m.kubeConfigPath)) == 1 {
+		args = append(args, "--kubeconfig="+m.kubeConfigPath)
+	}
+	if m.kubeContext != "" {
+		args = append(args, "--context="+m.kubeContext)
+	}
+
+	// the session outlives the context of the caller, it's stopped by Close
+	cmd := exec.Command("kubectl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s := &session{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+	addresses := make(chan string, 1)
+	go func() {
+		defer close(s.done)
+		scanner := bufio.NewScanner(stdout)
+		reported := false
+		for scanner.Scan() {
+			if address, ok := parseForwardingAddress(scanner.Text()); ok && !reported {
+				addresses <- address
+				reported = true
+			}
+		}
+		// drain the output so that kubectl never blocks on a full pipe
+		io.Copy(ioutil.Discard, stdout)
+		cmd.Wait()
+	}()
+
+	timer := time.NewTimer(m.startTimeout)
+	defer timer.Stop()
+	select {
+	case s.address = <-addresses:
+		return s, nil
+	case <-s.done:
+		return nil, fmt.Errorf("kubectl port-forward exited")
+	case <-timer.C:
+		s.stop()
+		return nil, fmt.Errorf("timeout waiting for kubectl port-forward")
+	case <-ctx.Done():
+		s.stop()
+		return nil, ctx.Err()
+	}
+}
+
+// readyPod returns the name of a running and ready pod of the controller.
+func (m *Manager) readyPod(ctx context.Context, target Target) (string, error) {
+	var pods corev1.PodList
+	if err := m.kubeClient.List(ctx, &pods, client.InNamespace(target.Namespace),
+		client.MatchingLabels{"app": target.Controller}); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+				return pod.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no ready %s pod found in %s namespace", target.Controller, target.Namespace)
+}
+
+func (s *session) stop() {
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+	<-s.done
+}
+
+var forwardingRegexp = regexp.MustCompile(`^Forwarding from (127\.0\.0\.1:\d+) -> \d+`)
+
+// parseForwardingAddress returns the local IPv4 address from a line
+// printed by kubectl port-forward, e.g.
+// "Forwarding from 127.0.0.1:53127 -> 9090".
+func parseForwardingAddress(line string) (string, bool) {
+	m := forwardingRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// blockingClient blocks the listing of the pods in the given namespace
+// until the context is done.
+type blockingClient struct {
+	client.Client
+	namespace string
+	listing   chan struct{}
+}
+
+func (c *blockingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.Namespace == c.namespace {
+		close(c.listing)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestAddressDoesNotBlockOtherTargets(t *testing.T) {
+	kubeClient := &blockingClient{
+		Client:    fake.NewClientBuilder().Build(),
+		namespace: "slow",
+		listing:   make(chan struct{}),
+	}
+	m := NewManager(kubeClient, "", "")
+	defer m.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	slow := make(chan error, 1)
+	go func() {
+		_, err := m.Address(ctx, Target{Namespace: "slow", Controller: "source-controller", Port: 9090})
+		slow <- err
+	}()
+	<-kubeClient.listing
+
+	// the start of the slow session must not hold the lock
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.Address(context.Background(), Target{Namespace: "flux-system", Controller: "source-controller", Port: 9090})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected an error as there is no pod")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Address blocked on the start of another target")
+	}
+
+	cancel()
+	if err := <-slow; err == nil {
+		t.Error("expected an error after the context is canceled")
+	}
+}
+
+func TestParseForwardingAddress(t *testing.T) {
+	tests := []struct {
+		line   string
+		expect string
+		ok     bool
+	}{
+		{"Forwarding from 127.0.0.1:53127 -> 9090", "127.0.0.1:53127", true},
+		{"Forwarding from [::1]:53127 -> 9090", "", false},
+		{"Handling connection for 53127", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			address, ok := parseForwardingAddress(tt.line)
+			if address != tt.expect || ok != tt.ok {
+				t.Errorf("parseForwardingAddress() = %v, %v, expect %v, %v", address, ok, tt.expect, tt.ok)
+			}
+		})
+	}
+}
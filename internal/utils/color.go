@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+const (
+	ColorReset  = "\033[0m"
+	ColorRed    = "\033[31m"
+	ColorGreen  = "\033[32m"
+	ColorYellow = "\033[33m"
+	ColorBlue   = "\033[34m"
+)
+
+var colorOutput bool
+
+// SetColorOutput enables or disables ANSI colors in tables.
+func SetColorOutput(enabled bool) {
+	colorOutput = enabled
+}
+
+// Colorize wraps the string in the given ANSI color, it is a no-op
+// when color output is disabled.
+func Colorize(color, str string) string {
+	if !colorOutput || str == "" {
+		return str
+	}
+	return color + str + ColorReset
+}
+
+func colorizeStatus(str string) string {
+	switch str {
+	case "True":
+		return Colorize(ColorGreen, str)
+	case "False":
+		return Colorize(ColorRed, str)
+	case "Unknown":
+		return Colorize(ColorYellow, str)
+	default:
+		return str
+	}
+}
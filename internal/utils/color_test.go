@@ -0,0 +1,62 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrintStatusTable(t *testing.T) {
+	SetColorOutput(true)
+	defer SetColorOutput(false)
+
+	header := []string{"Name", "Ready", "Suspended"}
+	rows := [][]string{
+		{"apps", "True", "True"},
+		{"infra", "False", "False"},
+	}
+	original := [][]string{
+		{"apps", "True", "True"},
+		{"infra", "False", "False"},
+	}
+
+	var buf bytes.Buffer
+	PrintStatusTable(&buf, header, rows, 1)
+
+	if !reflect.DeepEqual(rows, original) {
+		t.Errorf("rows were modified: %v", rows)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", buf.String())
+	}
+	if fields, expect := strings.Fields(lines[1]), []string{"apps", ColorGreen + "True" + ColorReset, "True"}; !reflect.DeepEqual(fields, expect) {
+		t.Errorf("fields = %q, expect %q", fields, expect)
+	}
+	if fields, expect := strings.Fields(lines[2]), []string{"infra", ColorRed + "False" + ColorReset, "False"}; !reflect.DeepEqual(fields, expect) {
+		t.Errorf("fields = %q, expect %q", fields, expect)
+	}
+
+	buf.Reset()
+	PrintTable(&buf, header, rows)
+	if strings.Contains(buf.String(), "\033[") {
+		t.Errorf("PrintTable output is colorized: %q", buf.String())
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultFieldManager is the field manager name used when none is set.
+const DefaultFieldManager = "flux"
+
+var (
+	fieldManager   = DefaultFieldManager
+	forceConflicts bool
+)
+
+// SetFieldManager sets the field manager recorded by the Kubernetes API for
+// the objects created, updated or applied by the CLI, and whether server-side
+// apply conflicts with other field managers are forced.
+func SetFieldManager(name string, force bool) {
+	fieldManager = name
+	forceConflicts = force
+}
+
+// KubectlApplyArgs returns the arguments of a kubectl apply command with
+// the field manager settings.
+func KubectlApplyArgs(args ...string) []string {
+	applyArgs := append([]string{"apply"}, args...)
+	applyArgs = append(applyArgs, fmt.Sprintf("--field-manager=%s", fieldManager))
+	if forceConflicts {
+		applyArgs = append(applyArgs, "--server-side", "--force-conflicts")
+	}
+	return applyArgs
+}
+
+// KubectlDiffArgs returns the arguments of a kubectl diff command with
+// the field manager settings, matching the ones of KubectlApplyArgs.
+func KubectlDiffArgs(args ...string) []string {
+	diffArgs := append([]string{"diff"}, args...)
+	diffArgs = append(diffArgs, fmt.Sprintf("--field-manager=%s", fieldManager))
+	if forceConflicts {
+		diffArgs = append(diffArgs, "--server-side", "--force-conflicts")
+	}
+	return diffArgs
+}
+
+// fieldManagerClient sets the field manager on all write requests, and
+// retries or summarises the ones rejected by admission webhooks.
+type fieldManagerClient struct {
+	client.Client
+}
+
+func (c fieldManagerClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return webhookWrite(obj, func() error {
+		return c.Client.Create(ctx, obj, append([]client.CreateOption{client.FieldOwner(fieldManager)}, opts...)...)
+	})
+}
+
+func (c fieldManagerClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return webhookWrite(obj, func() error {
+		return c.Client.Update(ctx, obj, append([]client.UpdateOption{client.FieldOwner(fieldManager)}, opts...)...)
+	})
+}
+
+func (c fieldManagerClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	patchOpts := []client.PatchOption{client.FieldOwner(fieldManager)}
+	if forceConflicts && patch.Type() == types.ApplyPatchType {
+		patchOpts = append(patchOpts, client.ForceOwnership)
+	}
+	return webhookWrite(obj, func() error {
+		return c.Client.Patch(ctx, obj, patch, append(patchOpts, opts...)...)
+	})
+}
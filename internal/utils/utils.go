@@ -20,16 +20,19 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	corev1 "k8s.io/api/core/v1"
@@ -37,6 +40,7 @@ import (
 	apiruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/jsonpath"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/k8sdeps/kunstruct"
@@ -87,7 +91,7 @@ func ExecKubectlCommand(ctx context.Context, mode ExecMode, kubeConfigPath strin
 
 	if mode == ModeStderrOS || mode == ModeOS {
 		if err := c.Run(); err != nil {
-			return "", err
+			return stderrBuf.String(), err
 		} else {
 			return "", nil
 		}
@@ -106,6 +110,34 @@ func ExecKubectlCommand(ctx context.Context, mode ExecMode, kubeConfigPath strin
 	return "", nil
 }
 
+// ExecKubectlDiff runs a kubectl diff command and returns the unified diff
+// between the live objects and the ones that would be applied. The diff is
+// empty when all objects are up to date.
+func ExecKubectlDiff(ctx context.Context, kubeConfigPath string, kubeContext string, args ...string) (string, error) {
+	var stdoutBuf, stderrBuf bytes.Buffer
+
+	if kubeConfigPath != "" && len(filepath.SplitList(kubeConfigPath)) == 1 {
+		args = append(args, "--kubeconfig="+kubeConfigPath)
+	}
+
+	if kubeContext != "" {
+		args = append(args, "--context="+kubeContext)
+	}
+
+	c := exec.CommandContext(ctx, "kubectl", args...)
+	c.Env = append(os.Environ(), "KUBECTL_EXTERNAL_DIFF=diff -u -N")
+	c.Stdout = &stdoutBuf
+	c.Stderr = &stderrBuf
+	if err := c.Run(); err != nil {
+		// kubectl diff exits with 1 when differences are found
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return stdoutBuf.String(), nil
+		}
+		return "", fmt.Errorf("%s: %w", strings.TrimSpace(stderrBuf.String()), err)
+	}
+	return stdoutBuf.String(), nil
+}
+
 func ExecTemplate(obj interface{}, tmpl, filename string) error {
 	t, err := template.New("tmpl").Parse(tmpl)
 	if err != nil {
@@ -136,7 +168,7 @@ func ExecTemplate(obj interface{}, tmpl, filename string) error {
 	return file.Sync()
 }
 
-func KubeConfig(kubeConfigPath string, kubeContext string) (*rest.Config, error) {
+func KubeConfig(kubeConfigPath string, kubeContext string, authTimeout time.Duration) (*rest.Config, error) {
 	configFiles := SplitKubeConfigPath(kubeConfigPath)
 	configOverrides := clientcmd.ConfigOverrides{}
 
@@ -153,15 +185,75 @@ func KubeConfig(kubeConfigPath string, kubeContext string) (*rest.Config, error)
 		return nil, fmt.Errorf("kubernetes configuration load failed: %w", err)
 	}
 
+	if cfg.ExecProvider != nil {
+		if err := checkExecProvider(cfg, authTimeout); err != nil {
+			return nil, err
+		}
+	}
+
 	return cfg, nil
 }
 
-func KubeClient(kubeConfigPath string, kubeContext string) (client.Client, error) {
-	cfg, err := KubeConfig(kubeConfigPath, kubeContext)
+// checkExecProvider verifies that the exec credential plugin of the
+// kubeconfig is installed, and waits for it to return credentials up to
+// the auth timeout. Plugins waiting on an interactive login would
+// otherwise block commands or fail them with opaque transport errors.
+// The credentials are cached by client-go for the clients created next.
+func checkExecProvider(cfg *rest.Config, authTimeout time.Duration) error {
+	command := cfg.ExecProvider.Command
+	if _, err := exec.LookPath(command); err != nil {
+		if hint := cfg.ExecProvider.InstallHint; hint != "" {
+			return fmt.Errorf("kubeconfig exec plugin '%s' not found in PATH: %s", command, hint)
+		}
+		return fmt.Errorf("kubeconfig exec plugin '%s' not found in PATH", command)
+	}
+
+	if authTimeout <= 0 {
+		return nil
+	}
+
+	transport, err := rest.TransportFor(cfg)
+	if err != nil {
+		return fmt.Errorf("kubernetes client initialization failed: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(cfg.Host, "/")+"/version", nil)
+	if err != nil {
+		return fmt.Errorf("kubernetes client initialization failed: %w", err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		resp, err := transport.RoundTrip(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		// connection errors are left to be reported by the command
+		if err != nil && strings.Contains(err.Error(), "getting credentials") {
+			return fmt.Errorf("kubeconfig exec plugin '%s' failed: %w", command, err)
+		}
+		return nil
+	case <-time.After(authTimeout):
+		return fmt.Errorf("timed out after %s waiting for credentials from kubeconfig exec plugin '%s', "+
+			"complete the login if it is interactive or increase --auth-timeout", authTimeout, command)
+	}
+}
+
+func KubeClient(kubeConfigPath string, kubeContext string, authTimeout time.Duration) (client.Client, error) {
+	cfg, err := KubeConfig(kubeConfigPath, kubeContext, authTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("kubernetes client initialization failed: %w", err)
 	}
+	return KubeClientForConfig(cfg)
+}
 
+// KubeClientForConfig returns a client for the given configuration, e.g.
+// one loaded from a kubeconfig stored in a secret.
+func KubeClientForConfig(cfg *rest.Config) (client.Client, error) {
 	scheme := apiruntime.NewScheme()
 	_ = corev1.AddToScheme(scheme)
 	_ = rbacv1.AddToScheme(scheme)
@@ -179,7 +271,7 @@ func KubeClient(kubeConfigPath string, kubeContext string) (client.Client, error
 		return nil, fmt.Errorf("kubernetes client initialization failed: %w", err)
 	}
 
-	return kubeClient, nil
+	return fieldManagerClient{kubeClient}, nil
 }
 
 // SplitKubeConfigPath splits the given KUBECONFIG path based on the runtime OS
@@ -365,6 +457,13 @@ func GenerateKustomizationYaml(dirPath string) error {
 }
 
 func PrintTable(writer io.Writer, header []string, rows [][]string) {
+	PrintStatusTable(writer, header, rows, -1)
+}
+
+// PrintStatusTable prints the rows like PrintTable, with the values of the
+// status column colorized when color output is enabled. The status column
+// is the index of the Ready column, a negative index disables colors.
+func PrintStatusTable(writer io.Writer, header []string, rows [][]string, statusColumn int) {
 	table := tablewriter.NewWriter(writer)
 	table.SetHeader(header)
 	table.SetAutoWrapText(false)
@@ -378,10 +477,71 @@ func PrintTable(writer io.Writer, header []string, rows [][]string) {
 	table.SetBorder(false)
 	table.SetTablePadding("\t")
 	table.SetNoWhiteSpace(true)
+	if colorOutput && statusColumn >= 0 {
+		colored := make([][]string, len(rows))
+		for i, row := range rows {
+			colored[i] = append([]string{}, row...)
+			if statusColumn < len(row) {
+				colored[i][statusColumn] = colorizeStatus(row[statusColumn])
+			}
+		}
+		rows = colored
+	}
 	table.AppendBulk(rows)
 	table.Render()
 }
 
+const (
+	OutputTable      = "table"
+	OutputGoTemplate = "go-template"
+	OutputJSONPath   = "jsonpath"
+)
+
+// ValidateOutputTemplate parses the template for the given output format
+// without executing it, so that syntax errors surface at flag parsing time.
+func ValidateOutputTemplate(format, tmpl string) error {
+	switch format {
+	case OutputGoTemplate:
+		_, err := template.New("output").Parse(tmpl)
+		return err
+	case OutputJSONPath:
+		return jsonpath.New("output").Parse(tmpl)
+	}
+	return nil
+}
+
+// PrintTemplate renders obj to the writer using a kubectl-style go-template
+// or jsonpath expression. The object is converted to its JSON representation
+// first, so templates refer to fields by their serialised names
+// (e.g. '{.items[*].metadata.name}').
+func PrintTemplate(writer io.Writer, format, tmpl string, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+
+	switch format {
+	case OutputGoTemplate:
+		t, err := template.New("output").Parse(tmpl)
+		if err != nil {
+			return err
+		}
+		return t.Execute(writer, generic)
+	case OutputJSONPath:
+		j := jsonpath.New("output")
+		j.AllowMissingKeys(true)
+		if err := j.Parse(tmpl); err != nil {
+			return err
+		}
+		return j.Execute(writer, generic)
+	}
+	return fmt.Errorf("unsupported output format '%s'", format)
+}
+
 func ValidateComponents(components []string) error {
 	defaults := install.MakeDefaultOptions()
 	bootstrapAllComponents := append(defaults.Components, defaults.ComponentsExtra...)
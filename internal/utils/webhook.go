@@ -0,0 +1,210 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var retryOnWebhookTimeout bool
+
+// webhookBackoff is used to retry the requests that failed because an
+// admission webhook could not be reached, it waits about 30s in total.
+var webhookBackoff = wait.Backoff{
+	Duration: 2 * time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+}
+
+var (
+	webhookDeniedRegexp = regexp.MustCompile(`(?s)admission webhook "([^"]+)" denied the request:\s*(.*)`)
+	kubectlObjectRegexp = regexp.MustCompile(`Name: "([^"]*)", Namespace: "([^"]*)"`)
+	kubectlKindRegexp   = regexp.MustCompile(`Kind=(\w+)`)
+	kubectlSourceRegexp = regexp.MustCompile(`(?:error when \w+|for:) "([^"]+)"`)
+)
+
+// SetWebhookRetry sets whether the writes that fail because an admission
+// webhook timed out or is unavailable are retried with backoff.
+func SetWebhookRetry(enabled bool) {
+	retryOnWebhookTimeout = enabled
+}
+
+// WebhookDenial holds the policy violations reported by an admission
+// webhook for an object.
+type WebhookDenial struct {
+	Object     string
+	Webhook    string
+	Violations []string
+}
+
+// WebhookDenialError is returned when admission webhooks (e.g. OPA
+// Gatekeeper or Kyverno) denied the creation or update of objects.
+type WebhookDenialError struct {
+	Denials []WebhookDenial
+}
+
+func (e *WebhookDenialError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "admission webhooks denied %d object(s):", len(e.Denials))
+	for _, d := range e.Denials {
+		fmt.Fprintf(&b, "\n  %s (%s):", d.Object, d.Webhook)
+		for _, v := range d.Violations {
+			fmt.Fprintf(&b, "\n    - %s", v)
+		}
+	}
+	return b.String()
+}
+
+// ExecKubectlApply runs a kubectl apply command, retrying it when an
+// admission webhook is unavailable and retries are enabled. When the
+// apply is denied by admission webhooks, a WebhookDenialError with the
+// violations of each object is returned.
+func ExecKubectlApply(ctx context.Context, mode ExecMode, kubeConfigPath string, kubeContext string, args ...string) (string, error) {
+	var output string
+	err := withWebhookRetry(func() error {
+		var err error
+		output, err = ExecKubectlCommand(ctx, mode, kubeConfigPath, kubeContext, args...)
+		if err != nil && isWebhookUnavailable(output) {
+			return &webhookUnavailableError{err}
+		}
+		return err
+	})
+	if err != nil {
+		if denials := parseKubectlDenials(output); len(denials) > 0 {
+			return output, &WebhookDenialError{Denials: denials}
+		}
+	}
+	return output, err
+}
+
+// webhookUnavailableError marks the kubectl failures caused by an
+// unreachable admission webhook.
+type webhookUnavailableError struct {
+	error
+}
+
+func (e *webhookUnavailableError) Unwrap() error {
+	return e.error
+}
+
+func withWebhookRetry(fn func() error) error {
+	if !retryOnWebhookTimeout {
+		return fn()
+	}
+	return retry.OnError(webhookBackoff, func(err error) bool {
+		if _, ok := err.(*webhookUnavailableError); ok {
+			return true
+		}
+		return isWebhookUnavailable(err.Error())
+	}, fn)
+}
+
+// isWebhookUnavailable tells if a request failed because an admission
+// webhook could not be called in time, as opposed to denying the request.
+func isWebhookUnavailable(msg string) bool {
+	msg = strings.ToLower(msg)
+	if !strings.Contains(msg, "failed calling webhook") {
+		return false
+	}
+	// the webhook URLs carry a timeout query parameter, only the network
+	// errors are matched
+	for _, reason := range []string{"deadline exceeded", "timeout exceeded", "i/o timeout", "connection refused", "no endpoints available", ": eof"} {
+		if strings.Contains(msg, reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookWrite runs a write request of the Kubernetes client with the
+// webhook retry settings, and summarises the admission webhook denials.
+func webhookWrite(obj client.Object, fn func() error) error {
+	err := withWebhookRetry(fn)
+	if err == nil || !apierrors.IsForbidden(err) && !apierrors.IsInvalid(err) && !apierrors.IsBadRequest(err) {
+		return err
+	}
+	m := webhookDeniedRegexp.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	object := fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		object = kind + "/" + object
+	}
+	return &WebhookDenialError{Denials: []WebhookDenial{{
+		Object:     object,
+		Webhook:    m[1],
+		Violations: splitViolations(m[2]),
+	}}}
+}
+
+// parseKubectlDenials extracts the admission webhook denials from the
+// errors printed by kubectl apply, one per object.
+func parseKubectlDenials(output string) []WebhookDenial {
+	var blocks []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Error from server") || len(blocks) == 0 {
+			blocks = append(blocks, line)
+			continue
+		}
+		blocks[len(blocks)-1] += "\n" + line
+	}
+
+	var denials []WebhookDenial
+	for _, block := range blocks {
+		m := webhookDeniedRegexp.FindStringSubmatch(block)
+		if m == nil {
+			continue
+		}
+		denial := WebhookDenial{
+			Webhook:    m[1],
+			Violations: splitViolations(m[2]),
+		}
+		if o := kubectlObjectRegexp.FindStringSubmatch(block); o != nil {
+			denial.Object = fmt.Sprintf("%s/%s", o[2], o[1])
+			if k := kubectlKindRegexp.FindStringSubmatch(block); k != nil {
+				denial.Object = k[1] + "/" + denial.Object
+			}
+		} else if s := kubectlSourceRegexp.FindStringSubmatch(block); s != nil {
+			denial.Object = s[1]
+		}
+		denials = append(denials, denial)
+	}
+	return denials
+}
+
+// splitViolations returns the non-empty lines of a denial message, as
+// policy engines report each violation on a separate line.
+func splitViolations(msg string) []string {
+	var violations []string
+	for _, line := range strings.Split(msg, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			violations = append(violations, line)
+		}
+	}
+	return violations
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKubectlDenials(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		expect []WebhookDenial
+	}{
+		{
+			name: "gatekeeper on create",
+			output: `namespace/apps created
+Error from server ([denied by ns-must-have-owner] you must provide labels: {"owner"}): error when creating "./infra/namespace.yaml": admission webhook "validation.gatekeeper.sh" denied the request: [denied by ns-must-have-owner] you must provide labels: {"owner"}
+`,
+			expect: []WebhookDenial{{
+				Object:     "./infra/namespace.yaml",
+				Webhook:    "validation.gatekeeper.sh",
+				Violations: []string{`[denied by ns-must-have-owner] you must provide labels: {"owner"}`},
+			}},
+		},
+		{
+			name: "gatekeeper on patch",
+			output: `Error from server (Forbidden): error when applying patch:
+{"metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{}"}}}
+to:
+Resource: "apps/v1, Resource=deployments", GroupVersionKind: "apps/v1, Kind=Deployment"
+Name: "podinfo", Namespace: "apps"
+for: "./apps/podinfo.yaml": admission webhook "validation.gatekeeper.sh" denied the request: [denied by container-must-have-limits] container <podinfod> has no resource limits
+[denied by repo-is-allowed] container <podinfod> has an invalid image repo <docker.io/stefanprodan/podinfo>
+`,
+			expect: []WebhookDenial{{
+				Object:  "Deployment/apps/podinfo",
+				Webhook: "validation.gatekeeper.sh",
+				Violations: []string{
+					"[denied by container-must-have-limits] container <podinfod> has no resource limits",
+					"[denied by repo-is-allowed] container <podinfod> has an invalid image repo <docker.io/stefanprodan/podinfo>",
+				},
+			}},
+		},
+		{
+			name: "kyverno on several objects",
+			output: `Error from server: error when creating "./apps/podinfo.yaml": admission webhook "validate.kyverno.svc-fail" denied the request: 
+
+resource Deployment/apps/podinfo was blocked due to the following policies
+
+require-labels:
+  check-for-labels: 'validation error: label ''app.kubernetes.io/name'' is required. Rule check-for-labels failed at path /metadata/labels/app.kubernetes.io/name/'
+Error from server: error when creating "./apps/redis.yaml": admission webhook "validate.kyverno.svc-fail" denied the request: 
+
+resource Deployment/apps/redis was blocked due to the following policies
+
+disallow-latest-tag:
+  validate-image-tag: 'validation error: Using a mutable image tag e.g. ''latest'' is not allowed. Rule validate-image-tag failed at path /spec/template/spec/containers/0/image/'
+`,
+			expect: []WebhookDenial{
+				{
+					Object:  "./apps/podinfo.yaml",
+					Webhook: "validate.kyverno.svc-fail",
+					Violations: []string{
+						"resource Deployment/apps/podinfo was blocked due to the following policies",
+						"require-labels:",
+						"check-for-labels: 'validation error: label ''app.kubernetes.io/name'' is required. Rule check-for-labels failed at path /metadata/labels/app.kubernetes.io/name/'",
+					},
+				},
+				{
+					Object:  "./apps/redis.yaml",
+					Webhook: "validate.kyverno.svc-fail",
+					Violations: []string{
+						"resource Deployment/apps/redis was blocked due to the following policies",
+						"disallow-latest-tag:",
+						"validate-image-tag: 'validation error: Using a mutable image tag e.g. ''latest'' is not allowed. Rule validate-image-tag failed at path /spec/template/spec/containers/0/image/'",
+					},
+				},
+			},
+		},
+		{
+			name: "not a denial",
+			output: `Error from server (NotFound): error when creating "./apps/podinfo.yaml": namespaces "apps" not found
+`,
+		},
+		{
+			name: "no errors",
+			output: `namespace/apps unchanged
+deployment.apps/podinfo configured
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if denials := parseKubectlDenials(tt.output); !reflect.DeepEqual(denials, tt.expect) {
+				t.Errorf("parseKubectlDenials() = %#v, expect %#v", denials, tt.expect)
+			}
+		})
+	}
+}
+
+func TestIsWebhookUnavailable(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    string
+		expect bool
+	}{
+		{
+			name:   "deadline exceeded",
+			msg:    `Internal error occurred: failed calling webhook "validation.gatekeeper.sh": Post "https://gatekeeper-webhook-service.gatekeeper-system.svc:443/v1/admit?timeout=3s": context deadline exceeded`,
+			expect: true,
+		},
+		{
+			name:   "client timeout",
+			msg:    `Internal error occurred: failed calling webhook "validate.kyverno.svc-fail": Post "https://kyverno-svc.kyverno.svc:443/validate?timeout=10s": net/http: request canceled while waiting for connection (Client.Timeout exceeded while awaiting headers)`,
+			expect: true,
+		},
+		{
+			name:   "connection refused",
+			msg:    `Internal error occurred: failed calling webhook "validate.kyverno.svc-fail": Post "https://kyverno-svc.kyverno.svc:443/validate?timeout=10s": dial tcp 10.96.112.7:443: connect: connection refused`,
+			expect: true,
+		},
+		{
+			name:   "no endpoints",
+			msg:    `Internal error occurred: failed calling webhook "validation.gatekeeper.sh": Post "https://gatekeeper-webhook-service.gatekeeper-system.svc:443/v1/admit?timeout=3s": no endpoints available for service "gatekeeper-webhook-service"`,
+			expect: true,
+		},
+		{
+			name:   "eof",
+			msg:    `Internal error occurred: failed calling webhook "validation.gatekeeper.sh": Post "https://gatekeeper-webhook-service.gatekeeper-system.svc:443/v1/admit?timeout=3s": EOF`,
+			expect: true,
+		},
+		{
+			name:   "certificate error",
+			msg:    `Internal error occurred: failed calling webhook "validation.gatekeeper.sh": Post "https://gatekeeper-webhook-service.gatekeeper-system.svc:443/v1/admit?timeout=3s": x509: certificate signed by unknown authority`,
+			expect: false,
+		},
+		{
+			name:   "denial",
+			msg:    `admission webhook "validation.gatekeeper.sh" denied the request: [denied by ns-must-have-owner] you must provide labels: {"owner"}`,
+			expect: false,
+		},
+		{
+			name:   "api server timeout",
+			msg:    `Get "https://127.0.0.1:6443/api?timeout=32s": context deadline exceeded`,
+			expect: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isWebhookUnavailable(tt.msg); got != tt.expect {
+				t.Errorf("isWebhookUnavailable() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestSplitViolations(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    string
+		expect []string
+	}{
+		{
+			name:   "single line",
+			msg:    `[denied by ns-must-have-owner] you must provide labels: {"owner"}`,
+			expect: []string{`[denied by ns-must-have-owner] you must provide labels: {"owner"}`},
+		},
+		{
+			name: "blank and indented lines",
+			msg: `
+
+require-labels:
+  check-for-labels: 'validation error: label ''app'' is required'
+`,
+			expect: []string{"require-labels:", "check-for-labels: 'validation error: label ''app'' is required'"},
+		},
+		{
+			name: "empty",
+			msg:  " \n ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitViolations(tt.msg); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("splitViolations() = %q, expect %q", got, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hooks notifies local extensions when a flux command completes,
+// so that usage can be audited without sending data anywhere by default.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// EnvVar is the environment variable holding the list of executables
+// to invoke after each command, separated by the OS path list separator.
+const EnvVar = "FLUX_CLI_HOOKS"
+
+// Event describes the execution of a command.
+type Event struct {
+	// Command is the full command path, e.g. "flux get kustomizations".
+	Command string
+	// Resource is the object the command operated on, e.g.
+	// "kustomization/flux-system/apps", empty when not applicable.
+	Resource string
+	// Duration is the time it took to run the command.
+	Duration time.Duration
+	// Error is the error returned by the command, nil on success.
+	Error error
+}
+
+// Hook is invoked after a command has run.
+type Hook interface {
+	CommandCompleted(ctx context.Context, event Event) error
+}
+
+var (
+	registeredMu sync.Mutex
+	registered   []Hook
+)
+
+// Register adds a hook invoked after each command, in addition to the
+// executables listed in the FLUX_CLI_HOOKS environment variable. It is
+// meant to be called from the init function of the packages built into
+// a custom flux binary.
+func Register(hook Hook) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	registered = append(registered, hook)
+}
+
+// Registered returns the hooks added with Register.
+func Registered() []Hook {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	return append([]Hook(nil), registered...)
+}
+
+// ExecHook runs an executable with the event encoded as JSON on stdin.
+type ExecHook struct {
+	Path string
+}
+
+type execPayload struct {
+	Command         string  `json:"command"`
+	Resource        string  `json:"resource,omitempty"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Result          string  `json:"result"`
+	Error           string  `json:"error,omitempty"`
+}
+
+func newExecPayload(event Event) execPayload {
+	payload := execPayload{
+		Command:         event.Command,
+		Resource:        event.Resource,
+		DurationSeconds: event.Duration.Seconds(),
+		Result:          "success",
+	}
+	if event.Error != nil {
+		payload.Result = "failure"
+		payload.Error = event.Error.Error()
+	}
+	return payload
+}
+
+func (h ExecHook) CommandCompleted(ctx context.Context, event Event) error {
+	data, err := json.Marshal(newExecPayload(event))
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, h.Path)
+	cmd.Stdin = bytes.NewReader(data)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("hook %s failed: %w, output: %s", h.Path, err, string(output))
+	}
+	return nil
+}
+
+// FromEnv returns the executable hooks listed in the FLUX_CLI_HOOKS
+// environment variable, hooks are disabled when it is not set.
+func FromEnv() []Hook {
+	var result []Hook
+	for _, path := range filepath.SplitList(os.Getenv(EnvVar)) {
+		if path != "" {
+			result = append(result, ExecHook{Path: path})
+		}
+	}
+	return result
+}
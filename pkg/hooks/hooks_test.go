@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecHook(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	output := filepath.Join(tmpDir, "event.json")
+	script := filepath.Join(tmpDir, "hook.sh")
+	if err := ioutil.WriteFile(script, []byte("#!/bin/sh\ncat > "+output+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	event := Event{
+		Command:  "flux get kustomizations",
+		Duration: 1500 * time.Millisecond,
+		Error:    errors.New("no kustomizations found"),
+	}
+	if err := (ExecHook{Path: script}).CommandCompleted(context.TODO(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload execPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	expected := execPayload{
+		Command:         "flux get kustomizations",
+		DurationSeconds: 1.5,
+		Result:          "failure",
+		Error:           "no kustomizations found",
+	}
+	if payload != expected {
+		t.Errorf("payload = %+v, expect %+v", payload, expected)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	os.Setenv(EnvVar, "")
+	defer os.Unsetenv(EnvVar)
+	if hooks := FromEnv(); len(hooks) != 0 {
+		t.Errorf("expected no hooks, got %v", hooks)
+	}
+
+	os.Setenv(EnvVar, strings.Join([]string{"/usr/bin/audit", "/usr/bin/usage"}, string(os.PathListSeparator)))
+	hooks := FromEnv()
+	if len(hooks) != 2 {
+		t.Fatalf("expected 2 hooks, got %v", hooks)
+	}
+	if hooks[1].(ExecHook).Path != "/usr/bin/usage" {
+		t.Errorf("unexpected hook %v", hooks[1])
+	}
+}
+
+type recordingHook struct {
+	events []Event
+}
+
+func (h *recordingHook) CommandCompleted(ctx context.Context, event Event) error {
+	h.events = append(h.events, event)
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	defer func() { registered = nil }()
+
+	hook := &recordingHook{}
+	Register(hook)
+
+	hooks := Registered()
+	if len(hooks) != 1 || hooks[0] != hook {
+		t.Fatalf("Registered() = %v, expect the registered hook", hooks)
+	}
+	// the returned slice doesn't alias the registered hooks
+	hooks[0] = ExecHook{}
+	if Registered()[0] != hook {
+		t.Error("Registered() result modified the registered hooks")
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// Message returns a one line summary of the command outcome, e.g.
+// "flux reconcile kustomization succeeded for kustomization/flux-system/apps in 2m3s".
+func Message(event Event) string {
+	result := "succeeded"
+	if event.Error != nil {
+		result = "failed"
+	}
+	msg := fmt.Sprintf("%s %s", event.Command, result)
+	if event.Resource != "" {
+		msg += " for " + event.Resource
+	}
+	msg += " in " + event.Duration.Round(time.Second).String()
+	if event.Error != nil {
+		msg += ": " + event.Error.Error()
+	}
+	return msg
+}
+
+// WebhookHook posts the event encoded as JSON to a URL. The payload has
+// a "text" field holding the Message, as expected by the incoming
+// webhooks of Slack, Mattermost, Rocket.Chat and Microsoft Teams.
+type WebhookHook struct {
+	URL    string
+	Client *http.Client
+}
+
+type webhookPayload struct {
+	Text string `json:"text"`
+	execPayload
+}
+
+func (h WebhookHook) CommandCompleted(ctx context.Context, event Event) error {
+	data, err := json.Marshal(webhookPayload{
+		Text:        Message(event),
+		execPayload: newExecPayload(event),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification failed: %s returned %s", h.URL, resp.Status)
+	}
+	return nil
+}
+
+// DesktopHook shows the Message as a desktop notification, using
+// notify-send on Linux and osascript on macOS.
+type DesktopHook struct{}
+
+func (h DesktopHook) CommandCompleted(ctx context.Context, event Event) error {
+	title := "Flux"
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", title, Message(event))
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", Message(event), title)
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("desktop notification failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		event  Event
+		expect string
+	}{
+		{
+			"success",
+			Event{Command: "flux bootstrap github", Duration: 90*time.Second + 400*time.Millisecond},
+			"flux bootstrap github succeeded in 1m30s",
+		},
+		{
+			"failure with resource",
+			Event{
+				Command:  "flux reconcile kustomization",
+				Resource: "kustomization/flux-system/apps",
+				Duration: 5 * time.Minute,
+				Error:    errors.New("timed out waiting for the condition"),
+			},
+			"flux reconcile kustomization failed for kustomization/flux-system/apps in 5m0s: timed out waiting for the condition",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if msg := Message(tt.event); msg != tt.expect {
+				t.Errorf("Message() = %q, expect %q", msg, tt.expect)
+			}
+		})
+	}
+}
+
+func TestWebhookHook(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	event := Event{
+		Command:  "flux wait kustomization",
+		Resource: "kustomization/flux-system/apps",
+		Duration: 2 * time.Second,
+	}
+	if err := (WebhookHook{URL: server.URL}).CommandCompleted(context.TODO(), event); err != nil {
+		t.Fatal(err)
+	}
+	if payload["text"] != Message(event) {
+		t.Errorf("text = %v, expect %v", payload["text"], Message(event))
+	}
+	if payload["result"] != "success" || payload["resource"] != event.Resource {
+		t.Errorf("unexpected payload %v", payload)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+	if err := (WebhookHook{URL: failing.URL}).CommandCompleted(context.TODO(), event); err == nil {
+		t.Error("expected an error for a not found webhook")
+	}
+}
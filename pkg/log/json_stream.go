@@ -0,0 +1,48 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// NewJSONStreamReporter returns a ProgressReporter which writes the events
+// to w as newline-delimited JSON, each one stamped with the time it was
+// reported at.
+func NewJSONStreamReporter(w io.Writer) ProgressReporter {
+	var mu sync.Mutex
+	return ProgressReporterFunc(func(event Event) {
+		line := struct {
+			Time string `json:"time"`
+			Event
+		}{
+			Time:  time.Now().UTC().Format(time.RFC3339Nano),
+			Event: event,
+		}
+		data, err := json.Marshal(line)
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(append(data, '\n'))
+	})
+}
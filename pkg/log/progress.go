@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import "fmt"
+
+// EventType is the kind of progress reported by an operation.
+type EventType string
+
+const (
+	// ActionEvent is reported when an action is started.
+	ActionEvent EventType = "Action"
+	// GenerateEvent is reported when manifests are generated.
+	GenerateEvent EventType = "Generate"
+	// WaitingEvent is reported while waiting for a resource.
+	WaitingEvent EventType = "Waiting"
+	// SuccessEvent is reported when an action succeeded.
+	SuccessEvent EventType = "Success"
+	// FailureEvent is reported when an action failed.
+	FailureEvent EventType = "Failure"
+	// ConditionEvent is reported when a status condition of a resource changes.
+	ConditionEvent EventType = "Condition"
+	// ResultEvent is reported once, with the outcome of the operation.
+	ResultEvent EventType = "Result"
+)
+
+// ResourceRef identifies the Kubernetes resource an event is about.
+type ResourceRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// String returns the reference in the '<kind>/<namespace>/<name>' format.
+func (r ResourceRef) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// Condition is the state of a status condition of a resource.
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Event is a progress update of an operation.
+type Event struct {
+	Type      EventType    `json:"type"`
+	Message   string       `json:"message"`
+	Resource  *ResourceRef `json:"resource,omitempty"`
+	Condition *Condition   `json:"condition,omitempty"`
+}
+
+// ProgressReporter receives the progress of an operation, it allows tools
+// embedding the commands to render the progress their own way.
+type ProgressReporter interface {
+	Report(event Event)
+}
+
+// ProgressReporterFunc is an adapter to use a function as a ProgressReporter.
+type ProgressReporterFunc func(event Event)
+
+// Report calls f(event).
+func (f ProgressReporterFunc) Report(event Event) {
+	f(event)
+}
+
+// NewReporterLogger returns a Logger which forwards the messages to a
+// ProgressReporter, events are optionally tied to a resource.
+func NewReporterLogger(reporter ProgressReporter, resource *ResourceRef) Logger {
+	return reporterLogger{reporter: reporter, resource: resource}
+}
+
+type reporterLogger struct {
+	reporter ProgressReporter
+	resource *ResourceRef
+}
+
+func (l reporterLogger) report(t EventType, format string, a ...interface{}) {
+	l.reporter.Report(Event{
+		Type:     t,
+		Message:  fmt.Sprintf(format, a...),
+		Resource: l.resource,
+	})
+}
+
+func (l reporterLogger) Actionf(format string, a ...interface{}) {
+	l.report(ActionEvent, format, a...)
+}
+
+func (l reporterLogger) Generatef(format string, a ...interface{}) {
+	l.report(GenerateEvent, format, a...)
+}
+
+func (l reporterLogger) Waitingf(format string, a ...interface{}) {
+	l.report(WaitingEvent, format, a...)
+}
+
+func (l reporterLogger) Successf(format string, a ...interface{}) {
+	l.report(SuccessEvent, format, a...)
+}
+
+func (l reporterLogger) Failuref(format string, a ...interface{}) {
+	l.report(FailureEvent, format, a...)
+}
+
+// NewLoggerReporter returns a ProgressReporter which prints the events
+// with a Logger, the resource reference is prepended to the message.
+func NewLoggerReporter(logger Logger) ProgressReporter {
+	return ProgressReporterFunc(func(event Event) {
+		message := event.Message
+		if event.Resource != nil {
+			message = fmt.Sprintf("%s: %s", event.Resource, message)
+		}
+		switch event.Type {
+		case ActionEvent:
+			logger.Actionf("%s", message)
+		case GenerateEvent:
+			logger.Generatef("%s", message)
+		case WaitingEvent:
+			logger.Waitingf("%s", message)
+		case SuccessEvent:
+			logger.Successf("%s", message)
+		case FailureEvent:
+			logger.Failuref("%s", message)
+		case ConditionEvent:
+			if event.Condition != nil {
+				message = fmt.Sprintf("%s %s=%s", message, event.Condition.Type, event.Condition.Status)
+			}
+			logger.Waitingf("%s", message)
+		case ResultEvent:
+			logger.Actionf("%s", message)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) record(prefix, format string, a ...interface{}) {
+	l.lines = append(l.lines, prefix+" "+fmt.Sprintf(format, a...))
+}
+
+func (l *recordingLogger) Actionf(format string, a ...interface{}) { l.record("action", format, a...) }
+func (l *recordingLogger) Generatef(format string, a ...interface{}) {
+	l.record("generate", format, a...)
+}
+func (l *recordingLogger) Waitingf(format string, a ...interface{}) {
+	l.record("waiting", format, a...)
+}
+func (l *recordingLogger) Successf(format string, a ...interface{}) {
+	l.record("success", format, a...)
+}
+func (l *recordingLogger) Failuref(format string, a ...interface{}) {
+	l.record("failure", format, a...)
+}
+
+func TestReporterLogger(t *testing.T) {
+	var events []Event
+	resource := &ResourceRef{Kind: "Kustomization", Namespace: "flux-system", Name: "apps"}
+	logger := NewReporterLogger(ProgressReporterFunc(func(e Event) {
+		events = append(events, e)
+	}), resource)
+
+	logger.Actionf("applying %s", "manifests")
+	logger.Waitingf("waiting")
+	logger.Successf("done")
+	logger.Failuref("failed")
+	logger.Generatef("generating")
+
+	expected := []Event{
+		{Type: ActionEvent, Message: "applying manifests", Resource: resource},
+		{Type: WaitingEvent, Message: "waiting", Resource: resource},
+		{Type: SuccessEvent, Message: "done", Resource: resource},
+		{Type: FailureEvent, Message: "failed", Resource: resource},
+		{Type: GenerateEvent, Message: "generating", Resource: resource},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("events = %v, expected %v", events, expected)
+	}
+}
+
+func TestLoggerReporter(t *testing.T) {
+	logger := &recordingLogger{}
+	reporter := NewLoggerReporter(logger)
+
+	reporter.Report(Event{Type: ActionEvent, Message: "reconciling"})
+	reporter.Report(Event{Type: SuccessEvent, Message: "100% done",
+		Resource: &ResourceRef{Kind: "GitRepository", Name: "podinfo"}})
+
+	expected := []string{
+		"action reconciling",
+		"success GitRepository/podinfo: 100% done",
+	}
+	if !reflect.DeepEqual(logger.lines, expected) {
+		t.Errorf("lines = %v, expected %v", logger.lines, expected)
+	}
+}
+
+func TestJSONStreamReporter(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewJSONStreamReporter(&buf)
+
+	reporter.Report(Event{Type: WaitingEvent, Message: "waiting for reconciliation"})
+	reporter.Report(Event{Type: ConditionEvent, Message: "condition changed",
+		Resource:  &ResourceRef{Kind: "Kustomization", Namespace: "flux-system", Name: "apps"},
+		Condition: &Condition{Type: "Ready", Status: "True", Reason: "ReconciliationSucceeded"}})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+
+	var decoded []map[string]interface{}
+	for _, line := range lines {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, m["time"].(string)); err != nil {
+			t.Errorf("invalid time in %q: %v", line, err)
+		}
+		delete(m, "time")
+		decoded = append(decoded, m)
+	}
+
+	expected := []map[string]interface{}{
+		{"type": "Waiting", "message": "waiting for reconciliation"},
+		{
+			"type":     "Condition",
+			"message":  "condition changed",
+			"resource": map[string]interface{}{"kind": "Kustomization", "namespace": "flux-system", "name": "apps"},
+			"condition": map[string]interface{}{
+				"type": "Ready", "status": "True", "reason": "ReconciliationSucceeded",
+			},
+		},
+	}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("events = %v, expected %v", decoded, expected)
+	}
+}
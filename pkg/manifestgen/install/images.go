@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Images returns the container images, sorted and without duplicates,
+// referenced by the workloads of the given multi-document YAML manifests.
+func Images(manifests string) ([]string, error) {
+	found := make(map[string]bool)
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(manifests), 2048)
+	for {
+		var obj unstructured.Unstructured
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode manifests: %w", err)
+		}
+		if obj.Object == nil {
+			continue
+		}
+
+		for _, field := range []string{"containers", "initContainers"} {
+			containers, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s in %s/%s: %w", field, obj.GetKind(), obj.GetName(), err)
+			}
+			for _, c := range containers {
+				container, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if image, ok := container["image"].(string); ok && image != "" {
+					found[image] = true
+				}
+			}
+		}
+	}
+
+	images := make([]string, 0, len(found))
+	for image := range found {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImages(t *testing.T) {
+	manifests := `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: flux-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: source-controller
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox:1.32
+      containers:
+      - name: manager
+        image: ghcr.io/fluxcd/source-controller:v0.7.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: kustomize-controller
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: ghcr.io/fluxcd/kustomize-controller:v0.7.0
+      - name: sidecar
+        image: busybox:1.32
+`
+	images, err := Images(manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"busybox:1.32",
+		"ghcr.io/fluxcd/kustomize-controller:v0.7.0",
+		"ghcr.io/fluxcd/source-controller:v0.7.0",
+	}
+	if !reflect.DeepEqual(images, expected) {
+		t.Errorf("images = %v, expected %v", images, expected)
+	}
+}
@@ -52,7 +52,16 @@ func Generate(options Options) (*manifestgen.Manifest, error) {
 			return nil, err
 		}
 	} else {
-		if err := fetch(ctx, options.BaseURL, options.Version, tmpDir); err != nil {
+		// callers which print the version resolve it beforehand
+		version := options.Version
+		if !IsExactVersion(version) {
+			version, err = ResolveVersion(ctx, options.BaseURL, version)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := fetch(ctx, options.BaseURL, version, options.CacheDir, tmpDir); err != nil {
 			return nil, err
 		}
 
@@ -17,8 +17,12 @@ limitations under the License.
 package install
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
@@ -31,12 +35,42 @@ import (
 	"github.com/fluxcd/pkg/untar"
 )
 
-func fetch(ctx context.Context, url, version, dir string) error {
+func fetch(ctx context.Context, url, version, cacheDir, dir string) error {
 	ghURL := fmt.Sprintf("%s/latest/download/manifests.tar.gz", url)
 	if strings.HasPrefix(version, "v") {
 		ghURL = fmt.Sprintf("%s/download/%s/manifests.tar.gz", url, version)
 	}
 
+	// only released versions are cached, as latest changes over time
+	var cachePath string
+	if cacheDir != "" && strings.HasPrefix(version, "v") {
+		cachePath = filepath.Join(cacheDir, version, "manifests.tar.gz")
+	}
+
+	// a cached tarball is used, without network access, when it matches the
+	// checksum stored next to it, otherwise it's downloaded again
+	if cachePath != "" {
+		data, err := ioutil.ReadFile(cachePath)
+		if err == nil {
+			if checksum, err := ioutil.ReadFile(cachePath + ".sha256"); err == nil && verifyChecksum(data, strings.TrimSpace(string(checksum))) {
+				if _, err = untar.Untar(bytes.NewReader(data), dir); err != nil {
+					return fmt.Errorf("failed to untar manifests.tar.gz from %s, error: %w", cachePath, err)
+				}
+				return nil
+			}
+		}
+	}
+
+	// the checksum is published for released versions only
+	var checksum string
+	if strings.HasPrefix(version, "v") {
+		var err error
+		checksum, err = fetchChecksum(ctx, url, version)
+		if err != nil {
+			return err
+		}
+	}
+
 	req, err := http.NewRequest("GET", ghURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create HTTP request for %s, error: %w", ghURL, err)
@@ -54,14 +88,39 @@ func fetch(ctx context.Context, url, version, dir string) error {
 		return fmt.Errorf("failed to download manifests.tar.gz from %s, status: %s", ghURL, resp.Status)
 	}
 
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to download manifests.tar.gz from %s, error: %w", ghURL, err)
+	}
+
+	// verify checksum
+	if checksum != "" && !verifyChecksum(data, checksum) {
+		return fmt.Errorf("checksum mismatch for manifests.tar.gz from %s, expected %s", ghURL, checksum)
+	}
+
 	// extract
-	if _, err = untar.Untar(resp.Body, dir); err != nil {
+	if _, err = untar.Untar(bytes.NewReader(data), dir); err != nil {
 		return fmt.Errorf("failed to untar manifests.tar.gz from %s, error: %w", ghURL, err)
 	}
 
+	// failing to write the cache doesn't prevent the install
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			if err := ioutil.WriteFile(cachePath, data, 0644); err == nil {
+				_ = ioutil.WriteFile(cachePath+".sha256", []byte(checksum+"\n"), 0644)
+			}
+		}
+	}
+
 	return nil
 }
 
+// verifyChecksum tells if the data matches the SHA256 checksum.
+func verifyChecksum(data []byte, checksum string) bool {
+	sum := sha256.Sum256(data)
+	return checksum != "" && hex.EncodeToString(sum[:]) == checksum
+}
+
 func generate(base string, options Options) error {
 	if containsItemString(options.Components, options.NotificationController) {
 		options.EventsAddr = fmt.Sprintf("http://%s/", options.NotificationController)
@@ -71,6 +130,12 @@ func generate(base string, options Options) error {
 		return fmt.Errorf("generate namespace failed: %w", err)
 	}
 
+	if len(options.ServiceAccountAnnotations) > 0 {
+		if err := execTemplate(options, serviceAccountTmpl, path.Join(base, "service-account.yaml")); err != nil {
+			return fmt.Errorf("generate service account failed: %w", err)
+		}
+	}
+
 	if err := execTemplate(options, labelsTmpl, path.Join(base, "labels.yaml")); err != nil {
 		return fmt.Errorf("generate labels failed: %w", err)
 	}
@@ -35,6 +35,18 @@ type Options struct {
 	Timeout                time.Duration
 	TargetPath             string
 	ClusterDomain          string
+
+	// CacheDir is where the manifests of released versions are stored
+	// after download, caching is disabled when empty.
+	CacheDir string
+
+	// ServiceAccountAnnotations are set on the service account used by
+	// the controllers, e.g. for IRSA or Workload Identity.
+	ServiceAccountAnnotations map[string]string
+
+	// SkipNamespace omits the namespace object from the manifests,
+	// leaving a namespace created by other means untouched.
+	SkipNamespace bool
 }
 
 func MakeDefaultOptions() Options {
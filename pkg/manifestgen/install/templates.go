@@ -38,7 +38,12 @@ transformers:
   - labels.yaml
 
 resources:
+{{- if not .SkipNamespace }}
   - namespace.yaml
+{{- end }}
+{{- if .ServiceAccountAnnotations }}
+  - service-account.yaml
+{{- end }}
 {{- if .NetworkPolicy }}
   - policies.yaml
 {{- end }}
@@ -158,6 +163,17 @@ metadata:
   name: {{.Namespace}}
 `
 
+var serviceAccountTmpl = `---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: default
+  annotations:
+{{- range $key, $value := .ServiceAccountAnnotations }}
+    {{ printf "%q" $key }}: {{ printf "%q" $value }}
+{{- end }}
+`
+
 func execTemplate(obj interface{}, tmpl, filename string) error {
 	t, err := template.New("tmpl").Parse(tmpl)
 	if err != nil {
@@ -0,0 +1,237 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// HelmChartIndexURL is the index of the Helm repository which serves the
+// community chart (https://github.com/fluxcd-community/helm-charts).
+const HelmChartIndexURL = "https://fluxcd-community.github.io/helm-charts/index.yaml"
+
+const helmChartName = "flux2"
+
+// HelmChart holds the version and the default values of the community
+// chart which deploys a Flux version.
+type HelmChart struct {
+	Version string
+	Values  map[string]interface{}
+}
+
+// FetchHelmChart downloads the community chart whose appVersion is the
+// given Flux version and reads its default values.
+func FetchHelmChart(ctx context.Context, indexURL, version string) (*HelmChart, error) {
+	base, err := url.Parse(indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Helm repository index URL '%s': %w", indexURL, err)
+	}
+	data, err := download(ctx, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	var index struct {
+		Entries map[string][]struct {
+			Version    string   `json:"version"`
+			AppVersion string   `json:"appVersion"`
+			URLs       []string `json:"urls"`
+		} `json:"entries"`
+	}
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode Helm repository index %s, error: %w", indexURL, err)
+	}
+
+	for _, entry := range index.Entries[helmChartName] {
+		if strings.TrimPrefix(entry.AppVersion, "v") != strings.TrimPrefix(version, "v") || len(entry.URLs) == 0 {
+			continue
+		}
+		chartURL, err := base.Parse(entry.URLs[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid chart URL '%s': %w", entry.URLs[0], err)
+		}
+		archive, err := download(ctx, chartURL.String())
+		if err != nil {
+			return nil, err
+		}
+		values, err := readHelmChartValues(archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the values of %s, error: %w", chartURL, err)
+		}
+		return &HelmChart{Version: entry.Version, Values: values}, nil
+	}
+	return nil, fmt.Errorf("no %s chart found for version %s in %s", helmChartName, version, indexURL)
+}
+
+// readHelmChartValues returns the values.yaml of a packaged chart.
+func readHelmChartValues(archive []byte) (map[string]interface{}, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("values.yaml not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Name != helmChartName+"/values.yaml" {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s, error: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s, status: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// HelmValues returns the install options as values for the community
+// Helm chart, so that the same configuration can be used when Flux is
+// deployed with Helm. Only the keys of the chart default values are
+// set, an option which differs from its default and has no key in the
+// chart is an error.
+func HelmValues(options Options, chart *HelmChart) (string, error) {
+	values := map[string]interface{}{}
+	var unsupported []string
+	set := func(value interface{}, isDefault bool, path ...string) {
+		if !hasValue(chart.Values, path) {
+			if !isDefault {
+				unsupported = append(unsupported, strings.Join(path, "."))
+			}
+			return
+		}
+		setValue(values, path, value)
+	}
+
+	defaults := MakeDefaultOptions()
+	set(options.LogLevel, options.LogLevel == defaults.LogLevel, "logLevel")
+	set(options.WatchAllNamespaces, options.WatchAllNamespaces == defaults.WatchAllNamespaces, "watchAllNamespaces")
+	set(options.ClusterDomain, options.ClusterDomain == defaults.ClusterDomain, "clusterDomain")
+	set(options.NetworkPolicy, options.NetworkPolicy == defaults.NetworkPolicy, "policies", "create")
+	if options.ImagePullSecret != "" {
+		set([]map[string]string{{"name": options.ImagePullSecret}}, false, "imagePullSecrets")
+	}
+	for _, component := range append(defaults.Components, defaults.ComponentsExtra...) {
+		key := strings.ReplaceAll(component, "-", "")
+		create := containsItemString(options.Components, component)
+		set(create, create == containsItemString(defaults.Components, component), key, "create")
+		if create {
+			set(fmt.Sprintf("%s/%s", options.Registry, component), options.Registry == defaults.Registry, key, "image")
+		}
+	}
+
+	if len(unsupported) > 0 {
+		return "", fmt.Errorf("%s chart version %s has no values for %s",
+			helmChartName, chart.Version, strings.Join(unsupported, ", "))
+	}
+
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// hasValue tells if the values have a key at the path.
+func hasValue(values map[string]interface{}, path []string) bool {
+	for i, key := range path {
+		v, ok := values[key]
+		if !ok {
+			return false
+		}
+		if i == len(path)-1 {
+			return true
+		}
+		if values, ok = v.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return false
+}
+
+func setValue(values map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := values[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			values[key] = next
+		}
+		values = next
+	}
+	values[path[len(path)-1]] = value
+}
+
+// TerraformInstall returns the install options as a data source of the
+// Terraform provider (https://github.com/fluxcd/terraform-provider-flux),
+// so that the same configuration can be used when Flux is deployed with
+// Terraform.
+func TerraformInstall(options Options) string {
+	var components []string
+	for _, component := range options.Components {
+		components = append(components, fmt.Sprintf("%q", component))
+	}
+
+	var b strings.Builder
+	b.WriteString("data \"flux_install\" \"main\" {\n")
+	fmt.Fprintf(&b, "  target_path          = %q\n", options.TargetPath)
+	fmt.Fprintf(&b, "  version              = %q\n", options.Version)
+	fmt.Fprintf(&b, "  namespace            = %q\n", options.Namespace)
+	fmt.Fprintf(&b, "  components           = [%s]\n", strings.Join(components, ", "))
+	fmt.Fprintf(&b, "  registry             = %q\n", options.Registry)
+	fmt.Fprintf(&b, "  image_pull_secrets   = %q\n", options.ImagePullSecret)
+	fmt.Fprintf(&b, "  watch_all_namespaces = %t\n", options.WatchAllNamespaces)
+	fmt.Fprintf(&b, "  network_policy       = %t\n", options.NetworkPolicy)
+	fmt.Fprintf(&b, "  log_level            = %q\n", options.LogLevel)
+	fmt.Fprintf(&b, "  cluster_domain       = %q\n", options.ClusterDomain)
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const testChartValues = `
+logLevel: info
+watchAllNamespaces: true
+imagePullSecrets: []
+policies:
+  create: true
+sourcecontroller:
+  create: true
+  image: ghcr.io/fluxcd/source-controller
+  tag: v0.7.0
+kustomizecontroller:
+  create: true
+  image: ghcr.io/fluxcd/kustomize-controller
+helmcontroller:
+  create: true
+  image: ghcr.io/fluxcd/helm-controller
+notificationcontroller:
+  create: true
+  image: ghcr.io/fluxcd/notification-controller
+imagereflectorcontroller:
+  create: false
+  image: ghcr.io/fluxcd/image-reflector-controller
+imageautomationcontroller:
+  create: false
+  image: ghcr.io/fluxcd/image-automation-controller
+`
+
+func testHelmChart(t *testing.T) *HelmChart {
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(testChartValues), &values); err != nil {
+		t.Fatal(err)
+	}
+	return &HelmChart{Version: "0.2.0", Values: values}
+}
+
+func TestHelmValues(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.ImagePullSecret = "regcred"
+	output, err := HelmValues(opts, testHelmChart(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(output), &values); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"sourcecontroller":          true,
+		"kustomizecontroller":       true,
+		"helmcontroller":            true,
+		"notificationcontroller":    true,
+		"imagereflectorcontroller":  false,
+		"imageautomationcontroller": false,
+	}
+	for key, create := range tests {
+		component, ok := values[key].(map[string]interface{})
+		if !ok {
+			t.Errorf("component '%s' not found", key)
+			continue
+		}
+		if component["create"] != create {
+			t.Errorf("component '%s' create = %v, expect %v", key, component["create"], create)
+		}
+	}
+
+	if values["logLevel"] != opts.LogLevel {
+		t.Errorf("logLevel = %v, expect %v", values["logLevel"], opts.LogLevel)
+	}
+	if _, ok := values["clusterDomain"]; ok {
+		t.Errorf("clusterDomain is set but the chart has no such value")
+	}
+	if !strings.Contains(output, "name: regcred") {
+		t.Errorf("image pull secret '%s' not found", opts.ImagePullSecret)
+	}
+}
+
+func TestHelmValuesUnsupported(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.ClusterDomain = "cluster.example"
+	_, err := HelmValues(opts, testHelmChart(t))
+	if err == nil || !strings.Contains(err.Error(), "clusterDomain") {
+		t.Errorf("expected an error for clusterDomain, got %v", err)
+	}
+}
+
+func TestFetchHelmChart(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range map[string]string{
+		"flux2/Chart.yaml":  "name: flux2\nversion: 0.2.0\n",
+		"flux2/values.yaml": testChartValues,
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.yaml":
+			fmt.Fprint(w, `apiVersion: v1
+entries:
+  flux2:
+  - name: flux2
+    version: 0.2.0
+    appVersion: 0.7.0
+    urls:
+    - flux2-0.2.0.tgz
+  - name: flux2
+    version: 0.1.0
+    appVersion: 0.6.3
+    urls:
+    - flux2-0.1.0.tgz
+`)
+		case "/flux2-0.2.0.tgz":
+			w.Write(buf.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	chart, err := FetchHelmChart(context.TODO(), server.URL+"/index.yaml", "v0.7.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chart.Version != "0.2.0" {
+		t.Errorf("chart version = %s, expect 0.2.0", chart.Version)
+	}
+	if !hasValue(chart.Values, []string{"sourcecontroller", "tag"}) {
+		t.Errorf("chart values not read: %v", chart.Values)
+	}
+
+	if _, err := FetchHelmChart(context.TODO(), server.URL+"/index.yaml", "v0.8.0"); err == nil {
+		t.Errorf("expected an error for a version without chart")
+	}
+}
+
+func TestTerraformInstall(t *testing.T) {
+	opts := MakeDefaultOptions()
+	output := TerraformInstall(opts)
+
+	for _, component := range opts.Components {
+		if !strings.Contains(output, `"`+component+`"`) {
+			t.Errorf("component '%s' not found", component)
+		}
+	}
+	if !strings.Contains(output, `namespace            = "flux-system"`) {
+		t.Errorf("namespace not found")
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+var (
+	minorSelectorRegexp = regexp.MustCompile(`^v(\d+)\.(\d+)(\.x)?$`)
+	releasesURLRegexp   = regexp.MustCompile(`^(https?://[^/]+)/([^/]+)/([^/]+)/releases/?$`)
+)
+
+// ResolveVersion resolves a version selector to a release version. The
+// selector can be "latest", an exact version e.g. "v0.7.1", or a minor
+// release series e.g. "v0.7" or "v0.7.x" which resolves to its latest patch.
+func ResolveVersion(ctx context.Context, baseURL, selector string) (string, error) {
+	if selector == "latest" {
+		return resolveLatestVersion(ctx, baseURL)
+	}
+	if !minorSelectorRegexp.MatchString(selector) {
+		if _, err := semver.ParseTolerant(selector); err != nil || !strings.HasPrefix(selector, "v") {
+			return "", fmt.Errorf("invalid version '%s', must be 'latest', vX.Y.Z or vX.Y.x", selector)
+		}
+		return selector, nil
+	}
+
+	tags, err := listReleaseTags(ctx, baseURL)
+	if err != nil {
+		return "", err
+	}
+	return selectVersion(selector, tags)
+}
+
+// resolveLatestVersion follows the redirect of the latest release page
+// to the release tag, the selector is returned as is when the releases
+// are not served by GitHub.
+func resolveLatestVersion(ctx context.Context, baseURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL+"/latest", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the latest version from %s, error: %w", baseURL, err)
+	}
+	resp.Body.Close()
+
+	if version := path.Base(resp.Request.URL.Path); strings.HasPrefix(version, "v") {
+		return version, nil
+	}
+	return "latest", nil
+}
+
+// IsExactVersion tells if the version is a release version which does
+// not have to be resolved.
+func IsExactVersion(version string) bool {
+	if !strings.HasPrefix(version, "v") || minorSelectorRegexp.MatchString(version) {
+		return false
+	}
+	_, err := semver.ParseTolerant(version)
+	return err == nil
+}
+
+// releasesAPIURL returns the GitHub API endpoint listing the releases
+// served at the base URL, e.g. https://github.com/fluxcd/flux2/releases.
+// The releases of GitHub Enterprise hosts are listed with their API v3.
+func releasesAPIURL(baseURL string) (string, error) {
+	m := releasesURLRegexp.FindStringSubmatch(baseURL)
+	if m == nil {
+		return "", fmt.Errorf("can't list the releases of %s, version selectors are only supported for GitHub releases, use an exact version instead", baseURL)
+	}
+	host, owner, repo := m[1], m[2], m[3]
+	if host == "https://github.com" {
+		host = "https://api.github.com"
+	} else {
+		host += "/api/v3"
+	}
+	return fmt.Sprintf("%s/repos/%s/%s/releases?per_page=100", host, owner, repo), nil
+}
+
+// listReleaseTags returns the tags of the published releases, following
+// the pages of the GitHub API.
+func listReleaseTags(ctx context.Context, baseURL string) ([]string, error) {
+	apiURL, err := releasesAPIURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for apiURL != "" {
+		var releases []struct {
+			TagName    string `json:"tag_name"`
+			Draft      bool   `json:"draft"`
+			Prerelease bool   `json:"prerelease"`
+		}
+		next, err := getReleasesPage(ctx, apiURL, &releases)
+		if err != nil {
+			return nil, err
+		}
+		for _, release := range releases {
+			if !release.Draft && !release.Prerelease {
+				tags = append(tags, release.TagName)
+			}
+		}
+		apiURL = next
+	}
+	return tags, nil
+}
+
+// getReleasesPage decodes a page of releases and returns the URL of the
+// next page, if any.
+func getReleasesPage(ctx context.Context, apiURL string, releases interface{}) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+	// unauthenticated requests are rate limited per IP address
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to list releases from %s, error: %w", apiURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to list releases from %s, status: %s", apiURL, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(releases); err != nil {
+		return "", fmt.Errorf("failed to decode releases, error: %w", err)
+	}
+	return nextPageURL(resp.Header.Get("Link")), nil
+}
+
+// nextPageURL returns the URL of the "next" relation of a Link header,
+// e.g. `<https://api.github.com/repositories/1/releases?page=2>; rel="next"`.
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		segments := strings.Split(part, ";")
+		if len(segments) < 2 {
+			continue
+		}
+		target := strings.TrimSpace(segments[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range segments[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+			}
+		}
+	}
+	return ""
+}
+
+// selectVersion returns the highest version of the minor release series
+// matched by the selector.
+func selectVersion(selector string, tags []string) (string, error) {
+	m := minorSelectorRegexp.FindStringSubmatch(selector)
+	if m == nil {
+		return "", fmt.Errorf("invalid version selector '%s'", selector)
+	}
+	minor, err := semver.ParseTolerant(fmt.Sprintf("%s.%s.0", m[1], m[2]))
+	if err != nil {
+		return "", err
+	}
+
+	var latest *semver.Version
+	var result string
+	for _, tag := range tags {
+		v, err := semver.ParseTolerant(tag)
+		if err != nil || len(v.Pre) > 0 || v.Major != minor.Major || v.Minor != minor.Minor {
+			continue
+		}
+		if latest == nil || v.GT(*latest) {
+			latest = &v
+			result = tag
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("no release found for version %s", selector)
+	}
+	return result, nil
+}
+
+// fetchChecksum returns the SHA256 checksum of the release manifests as
+// published with the release binaries. It fails when the release has no
+// checksums file or the file has no entry for the manifests, as the
+// manifests can't be verified.
+func fetchChecksum(ctx context.Context, url, version string) (string, error) {
+	checksumsURL := fmt.Sprintf("%s/download/%s/flux_%s_checksums.txt", url, version, strings.TrimPrefix(version, "v"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checksumsURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksums from %s, error: %w", checksumsURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download checksums from %s, status: %s", checksumsURL, resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == "manifests.tar.gz" {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read checksums from %s, error: %w", checksumsURL, err)
+	}
+	return "", fmt.Errorf("no checksum for manifests.tar.gz in %s", checksumsURL)
+}
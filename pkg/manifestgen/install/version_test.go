@@ -0,0 +1,246 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSelectVersion(t *testing.T) {
+	tags := []string{"v0.8.0", "v0.7.10", "v0.7.2", "v0.7.1", "v0.7.11-rc.1", "v0.6.3"}
+	tests := []struct {
+		selector  string
+		expect    string
+		expectErr bool
+	}{
+		{"v0.7.x", "v0.7.10", false},
+		{"v0.7", "v0.7.10", false},
+		{"v0.6.x", "v0.6.3", false},
+		{"v0.8.x", "v0.8.0", false},
+		{"v0.5.x", "", true},
+		{"v0.x", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := selectVersion(tt.selector, tags)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("selectVersion() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if got != tt.expect {
+				t.Errorf("selectVersion() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestIsExactVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		expect  bool
+	}{
+		{"v0.7.1", true},
+		{"v0.7.11-rc.1", true},
+		{"v0.7", false},
+		{"v0.7.x", false},
+		{"latest", false},
+		{"0.7.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := IsExactVersion(tt.version); got != tt.expect {
+				t.Errorf("IsExactVersion() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestReleasesAPIURL(t *testing.T) {
+	tests := []struct {
+		baseURL   string
+		expect    string
+		expectErr bool
+	}{
+		{"https://github.com/fluxcd/flux2/releases", "https://api.github.com/repos/fluxcd/flux2/releases?per_page=100", false},
+		{"https://github.com/my-org/flux2-mirror/releases/", "https://api.github.com/repos/my-org/flux2-mirror/releases?per_page=100", false},
+		{"https://github.example.com/platform/flux2/releases", "https://github.example.com/api/v3/repos/platform/flux2/releases?per_page=100", false},
+		{"https://artifacts.example.com/flux2", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			got, err := releasesAPIURL(tt.baseURL)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("releasesAPIURL() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if got != tt.expect {
+				t.Errorf("releasesAPIURL() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func testManifestsTarball(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	content := []byte("apiVersion: v1\nkind: Namespace\n")
+	if err := tw.WriteHeader(&tar.Header{Name: "namespace.yaml", Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestFetchVerifiesCache(t *testing.T) {
+	tarball := testManifestsTarball(t)
+
+	var downloads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download/v0.7.0/flux_0.7.0_checksums.txt":
+			fmt.Fprintf(w, "%x  manifests.tar.gz\n", sha256.Sum256(tarball))
+		case "/download/v0.7.0/manifests.tar.gz":
+			downloads++
+			w.Write(tarball)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cacheDir, err := ioutil.TempDir("", "manifests-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	cachePath := filepath.Join(cacheDir, "v0.7.0", "manifests.tar.gz")
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(cachePath, []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(cachePath+".sha256", []byte(fmt.Sprintf("%x\n", sha256.Sum256(tarball))), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fetchManifests := func() {
+		t.Helper()
+		dir, err := ioutil.TempDir("", "manifests")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if err := fetch(context.TODO(), server.URL, "v0.7.0", cacheDir, dir); err != nil {
+			t.Fatalf("fetch() error = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "namespace.yaml")); err != nil {
+			t.Errorf("manifests not extracted: %v", err)
+		}
+	}
+
+	fetchManifests()
+	fetchManifests()
+	if downloads != 1 {
+		t.Errorf("downloads = %d, expect 1", downloads)
+	}
+	if cached, _ := ioutil.ReadFile(cachePath); !bytes.Equal(cached, tarball) {
+		t.Errorf("the tampered cache was not replaced")
+	}
+
+	// the cache is used offline
+	server.Close()
+	fetchManifests()
+}
+
+func TestFetchRequiresChecksum(t *testing.T) {
+	tarball := testManifestsTarball(t)
+	tests := []struct {
+		name      string
+		checksums string
+	}{
+		{"no checksums file", ""},
+		{"no manifests entry", fmt.Sprintf("%x  flux_0.7.0_linux_amd64.tar.gz\n", sha256.Sum256(tarball))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/download/v0.7.0/flux_0.7.0_checksums.txt" && tt.checksums != "":
+					fmt.Fprint(w, tt.checksums)
+				case r.URL.Path == "/download/v0.7.0/manifests.tar.gz":
+					w.Write(tarball)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			dir, err := ioutil.TempDir("", "manifests")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			if err := fetch(context.TODO(), server.URL, "v0.7.0", "", dir); err == nil {
+				t.Error("fetch() expected an error without checksum")
+			}
+		})
+	}
+}
+
+func TestListReleaseTagsPages(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/fluxcd/flux2/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next", <%s%s?page=2>; rel="last"`,
+				server.URL, r.URL.Path, server.URL, r.URL.Path))
+			fmt.Fprint(w, `[{"tag_name":"v0.8.0"},{"tag_name":"v0.8.0-rc.1","prerelease":true}]`)
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="first", <%s%s>; rel="prev"`,
+				server.URL, r.URL.Path, server.URL, r.URL.Path))
+			fmt.Fprint(w, `[{"tag_name":"v0.7.1"},{"tag_name":"v0.7.0"}]`)
+		}
+	}))
+	defer server.Close()
+
+	tags, err := listReleaseTags(context.TODO(), server.URL+"/fluxcd/flux2/releases")
+	if err != nil {
+		t.Fatalf("listReleaseTags() error = %v", err)
+	}
+	if got, expect := fmt.Sprint(tags), "[v0.8.0 v0.7.1 v0.7.0]"; got != expect {
+		t.Errorf("listReleaseTags() = %v, expect %v", got, expect)
+	}
+}
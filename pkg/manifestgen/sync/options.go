@@ -29,6 +29,9 @@ type Options struct {
 	TargetPath        string
 	ManifestFile      string
 	GitImplementation string
+	SecretRef         string
+	Ignore            []string
+	SparsePaths       []string
 }
 
 func MakeDefaultOptions() Options {
@@ -41,5 +44,6 @@ func MakeDefaultOptions() Options {
 		ManifestFile:      "gotk-sync.yaml",
 		TargetPath:        "",
 		GitImplementation: "",
+		SecretRef:         "flux-system",
 	}
 }
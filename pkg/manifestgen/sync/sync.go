@@ -34,6 +34,11 @@ import (
 )
 
 func Generate(options Options) (*manifestgen.Manifest, error) {
+	secretRef := options.SecretRef
+	if secretRef == "" {
+		secretRef = options.Name
+	}
+
 	gvk := sourcev1.GroupVersion.WithKind(sourcev1.GitRepositoryKind)
 	gitRepository := sourcev1.GitRepository{
 		TypeMeta: metav1.TypeMeta{
@@ -53,11 +58,14 @@ func Generate(options Options) (*manifestgen.Manifest, error) {
 				Branch: options.Branch,
 			},
 			SecretRef: &meta.LocalObjectReference{
-				Name: options.Name,
+				Name: secretRef,
 			},
 			GitImplementation: options.GitImplementation,
 		},
 	}
+	if ignore := ignoreRules(options); ignore != "" {
+		gitRepository.Spec.Ignore = &ignore
+	}
 
 	gitData, err := yaml.Marshal(gitRepository)
 	if err != nil {
@@ -99,6 +107,77 @@ func Generate(options Options) (*manifestgen.Manifest, error) {
 	}, nil
 }
 
+// ignoreRules returns the .sourceignore rules of the GitRepository. The
+// sparse paths are turned into rules that exclude everything else from
+// the artifact, re-including each parent directory, as gitignore can't
+// re-include a file whose parent is excluded. The target path and the
+// namespace directory holding the Flux manifests are always included so
+// that the cluster keeps syncing its own manifests.
+func ignoreRules(options Options) string {
+	var rules []string
+	seen := make(map[string]bool)
+	add := func(rule string) {
+		if !seen[rule] {
+			seen[rule] = true
+			rules = append(rules, rule)
+		}
+	}
+
+	if len(options.SparsePaths) > 0 {
+		add("/*")
+		paths := append([]string{}, options.SparsePaths...)
+		paths = append(paths, options.TargetPath, path.Join(options.TargetPath, options.Namespace))
+		for _, p := range sparseRoots(paths) {
+			segments := strings.Split(p, "/")
+			for i := range segments {
+				prefix := strings.Join(segments[:i+1], "/")
+				add(fmt.Sprintf("!/%s/", prefix))
+				if i < len(segments)-1 {
+					add(fmt.Sprintf("/%s/*", prefix))
+				}
+			}
+		}
+	}
+
+	for _, rule := range options.Ignore {
+		add(rule)
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return strings.Join(rules, "\n") + "\n"
+}
+
+// sparseRoots returns the cleaned paths, without the ones covered by a
+// parent path. Including a parent re-includes its whole content, the
+// rules of a nested path would exclude the siblings of the nested path.
+func sparseRoots(paths []string) []string {
+	var cleaned []string
+	for _, p := range paths {
+		p = strings.Trim(path.Clean("/"+p), "/")
+		if p != "" {
+			cleaned = append(cleaned, p)
+		}
+	}
+
+	var roots []string
+	seen := make(map[string]bool)
+	for _, p := range cleaned {
+		covered := false
+		for _, other := range cleaned {
+			if other != p && strings.HasPrefix(p, other+"/") {
+				covered = true
+				break
+			}
+		}
+		if !covered && !seen[p] {
+			seen[p] = true
+			roots = append(roots, p)
+		}
+	}
+	return roots
+}
+
 func resourceToString(data []byte) string {
 	data = bytes.Replace(data, []byte("  creationTimestamp: null\n"), []byte(""), 1)
 	data = bytes.Replace(data, []byte("status: {}\n"), []byte(""), 1)
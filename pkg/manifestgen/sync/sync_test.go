@@ -39,3 +39,54 @@ func TestGenerate(t *testing.T) {
 
 	fmt.Println(output.Content)
 }
+
+func TestGenerate_SecretRef(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.SecretRef = "git-credentials"
+	output, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.Content, "name: git-credentials") {
+		t.Errorf("secretRef '%s' not found", opts.SecretRef)
+	}
+}
+
+func TestIgnoreRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    string
+	}{
+		{"none", Options{}, ""},
+		{"ignore", Options{Ignore: []string{"*.md", "/docs/"}}, "*.md\n/docs/\n"},
+		{
+			"sparse",
+			Options{SparsePaths: []string{"apps/prod", "./infra/"}, Namespace: "flux-system", TargetPath: "clusters/prod"},
+			"/*\n!/apps/\n/apps/*\n!/apps/prod/\n!/infra/\n!/clusters/\n/clusters/*\n!/clusters/prod/\n",
+		},
+		{
+			"sparse parent of target",
+			Options{SparsePaths: []string{"clusters"}, TargetPath: "clusters/prod"},
+			"/*\n!/clusters/\n",
+		},
+		{
+			"sparse nested paths",
+			Options{SparsePaths: []string{"apps/prod", "apps", "apps/"}, TargetPath: "clusters/prod"},
+			"/*\n!/apps/\n!/clusters/\n/clusters/*\n!/clusters/prod/\n",
+		},
+		{
+			"sparse and ignore",
+			Options{SparsePaths: []string{"apps"}, Namespace: "flux-system", Ignore: []string{"*.md"}},
+			"/*\n!/apps/\n!/flux-system/\n*.md\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ignoreRules(tt.options); got != tt.want {
+				t.Errorf("ignoreRules() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}